@@ -0,0 +1,81 @@
+package fastrouter
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// MaxBodyBytes limits a route's request bodies to n bytes. A request whose
+// Content-Length exceeds n gets a 413 Request Entity Too Large before the
+// handler runs; otherwise reading past n fails with an *http.MaxBytesError and
+// the connection is closed after the response.
+func MaxBodyBytes(n int64) RouteOption {
+	return func(route *Route) {
+		route.MaxBodyBytes = n
+	}
+}
+
+// Consumes restricts a route to request bodies of the given media types, such
+// as "application/json" or "image/*". A request with a body of another type,
+// or without a Content-Type, gets a 415 Unsupported Media Type. Requests
+// without a body are not checked.
+func Consumes(mediaTypes ...string) RouteOption {
+	return func(route *Route) {
+		route.Consumes = make([]string, len(mediaTypes))
+		for i, mediaType := range mediaTypes {
+			route.Consumes[i] = strings.ToLower(mediaType)
+		}
+	}
+}
+
+// bodyHandler checks request bodies against a route's limits before calling
+// its handler
+type bodyHandler struct {
+	handler  http.Handler
+	maxBytes int64
+	consumes []string
+}
+
+func (h *bodyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.ServeHTTPParams(w, r, GetPathParams(r))
+}
+
+func (h *bodyHandler) ServeHTTPParams(w http.ResponseWriter, r *http.Request, params PathParams) {
+	hasBody := r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0
+	if hasBody && len(h.consumes) > 0 && !h.accepts(r.Header.Get("Content-Type")) {
+		http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+		return
+	}
+	if hasBody && h.maxBytes > 0 {
+		if r.ContentLength > h.maxBytes {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		// The caller still owns r, so the limited body goes on a copy
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.Body = http.MaxBytesReader(w, r.Body, h.maxBytes)
+		r = r2
+	}
+	serveMatched(w, r, h.handler, params)
+}
+
+// accepts reports whether contentType is one of the consumed media types,
+// ignoring parameters such as charset
+func (h *bodyHandler) accepts(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, consumed := range h.consumes {
+		if consumed == mediaType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(consumed, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package fastrouter
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyLimits(t *testing.T) {
+	var readErr error
+	echo := func(w http.ResponseWriter, r *http.Request, params PathParams) {
+		body, err := io.ReadAll(r.Body)
+		readErr = err
+		if err != nil {
+			return
+		}
+		io.WriteString(w, params["id"]+":"+string(body))
+	}
+
+	rb := NewRouterBuilder()
+	api := rb.Group("/api", Consumes("application/json"), MaxBodyBytes(16))
+	api.AddRouteFunc("GET", "/items/:id", echo)
+	api.AddRouteFunc("POST", "/items/:id", echo)
+	api.AddRouteFunc("POST", "/uploads/:id", echo, Consumes("image/*", "application/octet-stream"), MaxBodyBytes(1<<20))
+	router, err := rb.Build()
+	if err != nil {
+		t.Fatalf("Error building router: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name, method, path, contentType, body string
+		status                                int
+		response                              string
+	}{
+		{"json", "POST", "/api/items/1", "application/json; charset=utf-8", `{"a":1}`, http.StatusOK, `1:{"a":1}`},
+		{"upper case type", "POST", "/api/items/1", "Application/JSON", `{}`, http.StatusOK, `1:{}`},
+		{"wrong type", "POST", "/api/items/1", "text/plain", `{}`, http.StatusUnsupportedMediaType, ""},
+		{"missing type", "POST", "/api/items/1", "", `{}`, http.StatusUnsupportedMediaType, ""},
+		{"no body", "GET", "/api/items/2", "", "", http.StatusOK, "2:"},
+		{"too large", "POST", "/api/items/1", "application/json", strings.Repeat("x", 17), http.StatusRequestEntityTooLarge, ""},
+		{"route override", "POST", "/api/uploads/3", "image/png", strings.Repeat("x", 100), http.StatusOK, "3:" + strings.Repeat("x", 100)},
+		{"route override type", "POST", "/api/uploads/3", "application/json", `{}`, http.StatusUnsupportedMediaType, ""},
+	} {
+		var body io.Reader
+		if tc.body != "" {
+			body = strings.NewReader(tc.body)
+		}
+		req := httptest.NewRequest(tc.method, tc.path, body)
+		if tc.contentType != "" {
+			req.Header.Set("Content-Type", tc.contentType)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tc.status {
+			t.Errorf("%s: expected status %d, got %d", tc.name, tc.status, w.Code)
+		}
+		if tc.response != "" && w.Body.String() != tc.response {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.response, w.Body.String())
+		}
+	}
+
+	// Without a Content-Length the limit applies while reading
+	req := httptest.NewRequest("POST", "/api/items/1", io.MultiReader(strings.NewReader(strings.Repeat("x", 32))))
+	req.ContentLength = -1
+	req.Header.Set("Content-Type", "application/json")
+	body := req.Body
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	if req.Body != body {
+		t.Error("Expected the caller's request body to be left alone")
+	}
+	var maxErr *http.MaxBytesError
+	if !errors.As(readErr, &maxErr) || maxErr.Limit != 16 {
+		t.Errorf("Expected an *http.MaxBytesError, got %v", readErr)
+	}
+}
@@ -1,49 +1,17 @@
 package main
 
 import (
-	"context"
+	"embed"
 	"fmt"
+	"io/fs"
 	"log"
 	"net/http"
 
-	"github.com/example/fastrouter"
+	"github.com/jamra/fastrouter"
 )
 
-// PathParamsKey is a context key for storing path parameters
-type contextKey struct{}
-
-var pathParamsKey = &contextKey{}
-
-// Middleware to store path parameters in request context
-func withPathParams(handler http.Handler, params fastrouter.PathParams) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx := context.WithValue(r.Context(), pathParamsKey, params)
-		handler.ServeHTTP(w, r.WithContext(ctx))
-	})
-}
-
-// GetPathParams extracts path parameters from request context
-func getPathParams(r *http.Request) fastrouter.PathParams {
-	if params, ok := r.Context().Value(pathParamsKey).(fastrouter.PathParams); ok {
-		return params
-	}
-	return make(fastrouter.PathParams)
-}
-
-// Enhanced router that handles path parameters
-type EnhancedRouter struct {
-	*fastrouter.Router
-}
-
-func (er *EnhancedRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	handler, params := er.Router.Match(r.Method, r.URL.Path)
-	if handler != nil {
-		// Wrap handler with path parameters
-		withPathParams(handler, params).ServeHTTP(w, r)
-	} else {
-		http.NotFound(w, r)
-	}
-}
+//go:embed static
+var staticFiles embed.FS
 
 func main() {
 	// Create router builder
@@ -84,31 +52,27 @@ func main() {
 	}))
 
 	// User routes with parameters
-	rb.AddRoute("GET", "/api/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		params := getPathParams(r)
+	rb.AddRouteFunc("GET", "/api/users/:id", func(w http.ResponseWriter, r *http.Request, params fastrouter.PathParams) {
 		userID := params["id"]
 		fmt.Fprintf(w, "✅ Parameter route matched!\n\nUser ID: %s\nFull path: %s\n\nParameters: %v", 
 			userID, r.URL.Path, params)
-	}))
+	})
 
-	rb.AddRoute("GET", "/api/users/:id/posts", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		params := getPathParams(r)
+	rb.AddRouteFunc("GET", "/api/users/:id/posts", func(w http.ResponseWriter, r *http.Request, params fastrouter.PathParams) {
 		userID := params["id"]
 		fmt.Fprintf(w, "✅ Nested parameter route matched!\n\nPosts for user: %s\nFull path: %s\n\nParameters: %v", 
 			userID, r.URL.Path, params)
-	}))
+	})
 
-	rb.AddRoute("GET", "/api/users/:id/posts/:postId", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		params := getPathParams(r)
+	rb.AddRouteFunc("GET", "/api/users/:id/posts/:postId", func(w http.ResponseWriter, r *http.Request, params fastrouter.PathParams) {
 		userID := params["id"]
 		postID := params["postId"]
 		fmt.Fprintf(w, "✅ Multiple parameters matched!\n\nUser: %s\nPost: %s\nFull path: %s\n\nParameters: %v", 
 			userID, postID, r.URL.Path, params)
-	}))
+	})
 
 	// Page wildcard route - your main request!
-	rb.AddRoute("GET", "/page/*", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		params := getPathParams(r)
+	rb.AddRouteFunc("GET", "/page/*", func(w http.ResponseWriter, r *http.Request, params fastrouter.PathParams) {
 		subPath := params["*"]
 		w.Header().Set("Content-Type", "text/html")
 		fmt.Fprintf(w, `
@@ -137,15 +101,16 @@ func main() {
 </body>
 </html>
 		`, r.URL.Path, subPath)
-	}))
+	})
 
-	// File serving wildcard
-	rb.AddRoute("GET", "/static/*", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		params := getPathParams(r)
-		filePath := params["*"]
-		fmt.Fprintf(w, "✅ Static file wildcard matched!\n\nWould serve file: %s\nFull path: %s\n\n(In a real app, you'd serve the actual file here)", 
-			filePath, r.URL.Path)
-	}))
+	// File serving wildcard, backed by the embedded static directory
+	assets, err := fs.Sub(staticFiles, "static")
+	if err != nil {
+		log.Fatal("Failed to open static files:", err)
+	}
+	if err := rb.ServeFiles("/static/*", assets); err != nil {
+		log.Fatal("Failed to add file server:", err)
+	}
 
 	// Build the router
 	router, err := rb.Build()
@@ -153,9 +118,6 @@ func main() {
 		log.Fatal("Failed to build router:", err)
 	}
 
-	// Wrap in enhanced router for parameter handling
-	enhancedRouter := &EnhancedRouter{Router: router}
-
 	fmt.Println("🚀 Dynamic FastRouter server starting on http://localhost:8080")
 	fmt.Println("")
 	fmt.Println("✨ Your wildcard route /page/* is working!")
@@ -169,5 +131,5 @@ func main() {
 	fmt.Println("")
 	fmt.Println("Open http://localhost:8080 in your browser to test!")
 
-	log.Fatal(http.ListenAndServe(":8080", enhancedRouter))
+	log.Fatal(http.ListenAndServe(":8080", router))
 }
\ No newline at end of file
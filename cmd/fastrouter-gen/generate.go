@@ -0,0 +1,379 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jamra/fastrouter"
+)
+
+// route is a single line of the route spec
+type route struct {
+	method  string
+	path    string
+	handler string // Go expression evaluating to an http.Handler
+	line    int
+}
+
+// config controls the shape of the generated file
+type config struct {
+	Package string
+	Type    string
+	Source  string // spec file name, recorded in the generated header
+}
+
+// genNode is a build-time trie node mirroring fastrouter's node layout
+type genNode struct {
+	static     map[string]*genNode
+	params     []*genNode
+	paramName  string
+	isWild     bool
+	wild       *genNode
+	handlers   map[string]string // method -> handler expression
+	paramNames []string          // names of the params captured on the way to this node
+}
+
+func newGenNode(paramNames []string) *genNode {
+	return &genNode{
+		static:     make(map[string]*genNode),
+		handlers:   make(map[string]string),
+		paramNames: paramNames,
+	}
+}
+
+// parseSpec reads "METHOD /path handlerExpr" lines, skipping blanks and # comments.
+// Paths may use either :id and * or the ServeMux {id} and {path...} syntax.
+func parseSpec(r io.Reader) ([]route, error) {
+	var routes []route
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("line %d: expected 'METHOD /path handler', got %q", lineNo, line)
+		}
+		if !strings.HasPrefix(fields[1], "/") {
+			return nil, fmt.Errorf("line %d: path %q must start with '/'", lineNo, fields[1])
+		}
+		path, err := fastrouter.ConvertPattern(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNo, err)
+		}
+
+		routes = append(routes, route{
+			method:  strings.ToUpper(fields[0]),
+			path:    path,
+			handler: strings.Join(fields[2:], " "),
+			line:    lineNo,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("no routes defined")
+	}
+	return routes, nil
+}
+
+// buildTrie inserts every route, rejecting duplicates, misplaced wildcards,
+// repeated param names and wildcards renamed at the same position
+func buildTrie(routes []route) (*genNode, int, error) {
+	root := newGenNode(nil)
+	maxParams := 0
+
+	for _, rt := range routes {
+		segments := strings.Split(rt.path, "/")[1:]
+		if len(segments) == 1 && segments[0] == "" {
+			segments = []string{} // root path "/"
+		}
+
+		current := root
+		for i, segment := range segments {
+			switch {
+			case strings.HasPrefix(segment, "*"):
+				if i != len(segments)-1 {
+					return nil, 0, fmt.Errorf("line %d: wildcard must be the last segment of %q", rt.line, rt.path)
+				}
+				name := segment[1:]
+				if name == "" {
+					name = "*"
+				}
+				if containsName(current.paramNames, name) {
+					return nil, 0, fmt.Errorf("line %d: duplicate parameter %q in %q", rt.line, name, rt.path)
+				}
+				if current.wild != nil {
+					if wildName := current.wild.paramNames[len(current.wild.paramNames)-1]; wildName != name {
+						return nil, 0, fmt.Errorf("line %d: wildcard %q in %q conflicts with an earlier wildcard named %q", rt.line, segment, rt.path, wildName)
+					}
+				} else {
+					current.wild = newGenNode(appendName(current.paramNames, name))
+					current.wild.isWild = true
+				}
+				current = current.wild
+
+			case strings.HasPrefix(segment, ":"):
+				name := segment[1:]
+				if name == "" {
+					return nil, 0, fmt.Errorf("line %d: empty parameter name in %q", rt.line, rt.path)
+				}
+				if containsName(current.paramNames, name) {
+					return nil, 0, fmt.Errorf("line %d: duplicate parameter %q in %q", rt.line, name, rt.path)
+				}
+				var child *genNode
+				for _, p := range current.params {
+					if p.paramName == name {
+						child = p
+						break
+					}
+				}
+				if child == nil {
+					child = newGenNode(appendName(current.paramNames, name))
+					child.paramName = name
+					current.params = append(current.params, child)
+				}
+				current = child
+
+			default:
+				child, ok := current.static[segment]
+				if !ok {
+					child = newGenNode(current.paramNames)
+					current.static[segment] = child
+				}
+				current = child
+			}
+		}
+
+		if _, exists := current.handlers[rt.method]; exists {
+			return nil, 0, fmt.Errorf("line %d: duplicate route %s %s", rt.line, rt.method, rt.path)
+		}
+		current.handlers[rt.method] = rt.handler
+
+		// The wildcard is sliced from the path, only segment params need a slot in vals
+		captured := len(current.paramNames)
+		if current.isWild {
+			captured--
+		}
+		if captured > maxParams {
+			maxParams = captured
+		}
+	}
+
+	return root, maxParams, nil
+}
+
+// appendName copies names so sibling nodes never share a backing array
+func appendName(names []string, name string) []string {
+	out := make([]string, len(names), len(names)+1)
+	copy(out, names)
+	return append(out, name)
+}
+
+// containsName reports whether a param of that name was already captured
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// generate renders the matcher source and gofmt's it
+func generate(cfg config, routes []route) ([]byte, error) {
+	root, maxParams, err := buildTrie(routes)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{qual: "fastrouter."}
+	if cfg.Package == "fastrouter" {
+		g.qual = ""
+	}
+
+	g.printf("// Code generated by fastrouter-gen from %s. DO NOT EDIT.\n\n", cfg.Source)
+	g.printf("package %s\n\n", cfg.Package)
+	g.printf("import (\n\t\"net/http\"\n")
+	if g.qual != "" {
+		g.printf("\n\t\"github.com/jamra/fastrouter\"\n")
+	}
+	g.printf(")\n\n")
+
+	g.printf("// %s is a compiled matcher for the %d routes in %s\n", cfg.Type, len(routes), cfg.Source)
+	g.printf("type %s struct{}\n\n", cfg.Type)
+
+	g.printf("// Match finds a handler for the given method and path with the same semantics as\n")
+	g.printf("// fastrouter.Router.Match. Static routes return nil params; param and wildcard\n")
+	g.printf("// routes return pooled params that can be handed back with ReleaseParams.\n")
+	g.printf("func (%s) Match(method, path string) (http.Handler, %sPathParams) {\n", cfg.Type, g.qual)
+	g.printf("if path == \"\" || path[0] != '/' {\npath = \"/\" + path\n}\n")
+	if maxParams > 0 {
+		g.printf("var vals [%d]string\n", maxParams)
+	}
+	g.printf("p0 := 1\nif len(path) == 1 {\np0 = 2 // root path has no segments\n}\n")
+	g.emitNode(root, 0)
+	g.printf("return nil, nil\n}\n")
+
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+	return src, nil
+}
+
+// generator accumulates the unformatted matcher source
+type generator struct {
+	buf  bytes.Buffer
+	qual string // qualifier for fastrouter identifiers
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// emitNode writes the matching code for n. The variable p<depth> holds the
+// start of the next unconsumed segment, or len(path)+1 once all segments are consumed.
+func (g *generator) emitNode(n *genNode, depth int) {
+	pos := fmt.Sprintf("p%d", depth)
+
+	if len(n.handlers) > 0 {
+		g.printf("if %s > len(path) {\n", pos)
+		g.emitHandlers(n, "")
+		g.printf("}\n")
+	}
+
+	hasSegmentChildren := len(n.static) > 0 || len(n.params) > 0
+	wildHandlers := n.wild != nil && len(n.wild.handlers) > 0
+	if !hasSegmentChildren && !wildHandlers {
+		return
+	}
+
+	g.printf("if %s <= len(path) {\n", pos)
+
+	if hasSegmentChildren {
+		end := fmt.Sprintf("e%d", depth)
+		seg := fmt.Sprintf("seg%d", depth)
+		next := fmt.Sprintf("p%d", depth+1)
+
+		g.printf("%s := %s\n", end, pos)
+		g.printf("for %s < len(path) && path[%s] != '/' {\n%s++\n}\n", end, end, end)
+		g.printf("%s := path[%s:%s]\n", seg, pos, end)
+		g.printf("%s := %s + 1\n", next, end)
+
+		if len(n.static) > 0 {
+			g.emitStatic(n, seg, depth)
+		}
+
+		for _, child := range n.params {
+			g.printf("// :%s\n", child.paramName)
+			g.printf("vals[%d] = %s\n", len(child.paramNames)-1, seg)
+			g.emitNode(child, depth+1)
+		}
+	}
+
+	if wildHandlers {
+		g.printf("// *\n")
+		g.emitHandlers(n.wild, fmt.Sprintf("path[%s:]", pos))
+	}
+
+	g.printf("}\n")
+}
+
+// emitStatic switches on segment length, then first byte, then compares the literal
+func (g *generator) emitStatic(n *genNode, seg string, depth int) {
+	byLength := make(map[int][]string)
+	for literal := range n.static {
+		byLength[len(literal)] = append(byLength[len(literal)], literal)
+	}
+
+	lengths := make([]int, 0, len(byLength))
+	for l := range byLength {
+		lengths = append(lengths, l)
+	}
+	sort.Ints(lengths)
+
+	g.printf("switch len(%s) {\n", seg)
+	for _, l := range lengths {
+		g.printf("case %d:\n", l)
+		if l == 0 {
+			g.emitNode(n.static[""], depth+1)
+			continue
+		}
+
+		byFirst := make(map[byte][]string)
+		for _, literal := range byLength[l] {
+			byFirst[literal[0]] = append(byFirst[literal[0]], literal)
+		}
+		firsts := make([]int, 0, len(byFirst))
+		for b := range byFirst {
+			firsts = append(firsts, int(b))
+		}
+		sort.Ints(firsts)
+
+		g.printf("switch %s[0] {\n", seg)
+		for _, b := range firsts {
+			g.printf("case %s:\n", byteLiteral(byte(b)))
+			literals := byFirst[byte(b)]
+			sort.Strings(literals)
+			for _, literal := range literals {
+				g.printf("if %s == %s {\n", seg, strconv.Quote(literal))
+				g.emitNode(n.static[literal], depth+1)
+				g.printf("}\n")
+			}
+		}
+		g.printf("}\n")
+	}
+	g.printf("}\n")
+}
+
+// emitHandlers writes a method switch returning the handler and its params.
+// wildValue is the expression captured by a wildcard node, empty otherwise.
+func (g *generator) emitHandlers(n *genNode, wildValue string) {
+	methods := make([]string, 0, len(n.handlers))
+	for m := range n.handlers {
+		methods = append(methods, m)
+	}
+	sort.Strings(methods)
+
+	g.printf("switch method {\n")
+	for _, m := range methods {
+		g.printf("case %s:\n", strconv.Quote(m))
+		if len(n.paramNames) == 0 {
+			g.printf("return %s, nil\n", n.handlers[m])
+			continue
+		}
+
+		g.printf("params := %sAcquireParams()\n", g.qual)
+		for i, name := range n.paramNames {
+			if n.isWild && i == len(n.paramNames)-1 {
+				g.printf("params[%s] = %s\n", strconv.Quote(name), wildValue)
+				continue
+			}
+			g.printf("params[%s] = vals[%d]\n", strconv.Quote(name), i)
+		}
+		g.printf("return %s, params\n", n.handlers[m])
+	}
+	g.printf("}\n")
+}
+
+// byteLiteral renders b as a rune literal when printable, hex otherwise
+func byteLiteral(b byte) string {
+	if b >= 0x20 && b < 0x7f {
+		return strconv.QuoteRune(rune(b))
+	}
+	return fmt.Sprintf("0x%02x", b)
+}
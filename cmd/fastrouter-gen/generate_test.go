@@ -0,0 +1,115 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSpecErrors(t *testing.T) {
+	testCases := []struct {
+		name string
+		spec string
+		want string
+	}{
+		{"Empty", "# only a comment\n\n", "no routes defined"},
+		{"MissingHandler", "GET /users\n", "line 1: expected 'METHOD /path handler'"},
+		{"RelativePath", "\nGET users handler\n", "line 2: path \"users\" must start with '/'"},
+		{"WildcardNotWholeSegment", "GET /files/{name}.json handler\n", "line 1: pattern"},
+		{"CatchAllNotLast", "GET /files/{path...}/raw handler\n", "line 1: pattern"},
+		{"InvalidName", "GET /users/{1id} handler\n", "line 1: pattern"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseSpec(strings.NewReader(tc.spec))
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Expected error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestParseSpecServeMuxSyntax(t *testing.T) {
+	routes, err := parseSpec(strings.NewReader(`
+get /users/{id}            getUser
+GET /files/{path...}       files
+GET /posts/{$}             posts
+GET /teams/:team/*         teams
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := []route{
+		{method: "GET", path: "/users/:id", handler: "getUser", line: 2},
+		{method: "GET", path: "/files/*path", handler: "files", line: 3},
+		{method: "GET", path: "/posts/", handler: "posts", line: 4},
+		{method: "GET", path: "/teams/:team/*", handler: "teams", line: 5},
+	}
+	if len(routes) != len(want) {
+		t.Fatalf("Expected %d routes, got %v", len(want), routes)
+	}
+	for i := range want {
+		if routes[i] != want[i] {
+			t.Errorf("Route %d: expected %+v, got %+v", i, want[i], routes[i])
+		}
+	}
+}
+
+func TestBuildTrieErrors(t *testing.T) {
+	testCases := []struct {
+		name string
+		spec string
+		want string
+	}{
+		{"DuplicateRoute", "GET /users/:id a\nGET /users/:id b\n", "line 2: duplicate route GET /users/:id"},
+		{"DuplicateAcrossSyntaxes", "GET /users/:id a\nGET /users/{id} b\n", "line 2: duplicate route GET /users/:id"},
+		{"DuplicateParam", "GET /users/:id/posts/:id a\n", "line 1: duplicate parameter \"id\""},
+		{"DuplicateParamInWildcard", "GET /files/:path/{path...} a\n", "line 1: duplicate parameter \"path\""},
+		{"ConflictingWildcards", "GET /files/*path a\nPOST /files/*name b\n", "line 2: wildcard \"*name\""},
+		{"WildcardNotLast", "GET /files/*/raw a\n", "line 1: wildcard must be the last segment"},
+		{"EmptyParamName", "GET /users/: a\n", "line 1: empty parameter name"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			routes, err := parseSpec(strings.NewReader(tc.spec))
+			if err != nil {
+				t.Fatalf("Unexpected parse error: %v", err)
+			}
+			_, _, err = buildTrie(routes)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Expected error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestBuildTrieSharedPrefixes(t *testing.T) {
+	routes, err := parseSpec(strings.NewReader(`
+GET    /users/:id               a
+DELETE /users/{id}              b
+GET    /users/:id/posts/:postId c
+GET    /files/*path             d
+POST   /files/{path...}         e
+`))
+	if err != nil {
+		t.Fatalf("Unexpected parse error: %v", err)
+	}
+
+	root, maxParams, err := buildTrie(routes)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if maxParams != 2 {
+		t.Errorf("Expected 2 params at most, got %d", maxParams)
+	}
+
+	users := root.static["users"]
+	if len(users.params) != 1 || len(users.params[0].handlers) != 2 {
+		t.Errorf("Expected GET and DELETE on one :id node, got %+v", users.params)
+	}
+	if files := root.static["files"]; files.wild == nil || len(files.wild.handlers) != 2 {
+		t.Errorf("Expected GET and POST on one wildcard node, got %+v", files.wild)
+	}
+}
@@ -0,0 +1,79 @@
+// Command fastrouter-gen compiles a fixed route table into a specialized Go matcher.
+//
+// The route spec is a text file with one route per line:
+//
+//	# method  path                handler expression
+//	GET       /                   homeHandler
+//	GET       /users/:id          http.HandlerFunc(getUser)
+//	GET       /static/*           staticFiles
+//	GET       /assets/*filepath   assetFiles
+//	GET       /posts/{id}         postHandler
+//
+// Paths accept both the :id and * syntax and the http.ServeMux {id},
+// {path...} and {$} syntax.
+//
+// The handler expression is copied verbatim into the generated file and must
+// evaluate to an http.Handler in the target package. The generated type has a
+// Match(method, path string) (http.Handler, PathParams) method with the same
+// semantics as fastrouter.Router.Match, implemented as nested switch statements
+// with no maps, no recursion and no allocations for static routes.
+//
+// Typical use is through go generate:
+//
+//	//go:generate go run github.com/jamra/fastrouter/cmd/fastrouter-gen -spec routes.txt -o routes_gen.go -pkg api -type Routes
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	specPath := flag.String("spec", "", "route spec file (required)")
+	outPath := flag.String("o", "", "output Go file (default: stdout)")
+	pkgName := flag.String("pkg", os.Getenv("GOPACKAGE"), "package name of the generated file")
+	typeName := flag.String("type", "Routes", "name of the generated matcher type")
+	flag.Parse()
+
+	log.SetFlags(0)
+	log.SetPrefix("fastrouter-gen: ")
+
+	if *specPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *pkgName == "" {
+		log.Fatal("-pkg is required outside of go generate")
+	}
+
+	spec, err := os.Open(*specPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer spec.Close()
+
+	routes, err := parseSpec(spec)
+	if err != nil {
+		log.Fatalf("%s: %v", *specPath, err)
+	}
+
+	src, err := generate(config{
+		Package: *pkgName,
+		Type:    *typeName,
+		Source:  filepath.Base(*specPath),
+	}, routes)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *outPath == "" {
+		fmt.Print(string(src))
+		return
+	}
+	if err := os.WriteFile(*outPath, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,423 @@
+// Package chi translates go-chi/chi style registration onto fastrouter.
+// Services migrate by swapping the import path:
+//
+//	r := chi.NewRouter()
+//	r.Use(middleware.Logger)
+//	r.Route("/articles", func(r chi.Router) {
+//		r.Get("/", listArticles)
+//		r.Get("/{articleSlug:[a-z-]+}", getArticleBySlug)
+//		r.Get("/{articleID}", getArticle)
+//	})
+//	http.ListenAndServe(":3000", r)
+//
+// Patterns use {name}, {name:regexp} and a trailing *, read with URLParam.
+// Variables must span a whole segment. Routes, sub-routers created with Route
+// and mounted *Mux values are collected and built into one fastrouter.Router on
+// the first request.
+//
+// Unlike chi, middlewares run after routing, so they cannot change the path
+// being matched, and the NotFound and MethodNotAllowed handlers of mounted
+// sub-routers are ignored in favour of the root router's.
+package chi
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jamra/fastrouter"
+	"github.com/jamra/fastrouter/compat/internal/dispatch"
+)
+
+// Router is the routing interface implemented by Mux
+type Router interface {
+	http.Handler
+
+	// Use appends one or more middlewares onto the Router stack
+	Use(middlewares ...func(http.Handler) http.Handler)
+
+	// With adds inline middlewares for an endpoint handler
+	With(middlewares ...func(http.Handler) http.Handler) Router
+
+	// Group adds a new inline-Router along the current routing path, with a
+	// fresh middleware stack for the inline-Router
+	Group(fn func(r Router)) Router
+
+	// Route mounts a sub-Router along a pattern string
+	Route(pattern string, fn func(r Router)) Router
+
+	// Mount attaches another http.Handler along ./pattern/*
+	Mount(pattern string, h http.Handler)
+
+	// Handle and HandleFunc add routes for pattern that matches all HTTP methods
+	Handle(pattern string, h http.Handler)
+	HandleFunc(pattern string, h http.HandlerFunc)
+
+	// Method and MethodFunc add routes for pattern that matches the method
+	Method(method, pattern string, h http.Handler)
+	MethodFunc(method, pattern string, h http.HandlerFunc)
+
+	// HTTP-method routing along pattern
+	Connect(pattern string, h http.HandlerFunc)
+	Delete(pattern string, h http.HandlerFunc)
+	Get(pattern string, h http.HandlerFunc)
+	Head(pattern string, h http.HandlerFunc)
+	Options(pattern string, h http.HandlerFunc)
+	Patch(pattern string, h http.HandlerFunc)
+	Post(pattern string, h http.HandlerFunc)
+	Put(pattern string, h http.HandlerFunc)
+	Trace(pattern string, h http.HandlerFunc)
+
+	// NotFound defines a handler to respond whenever a route could not be found
+	NotFound(h http.HandlerFunc)
+
+	// MethodNotAllowed defines a handler to respond whenever a method is not
+	// allowed
+	MethodNotAllowed(h http.HandlerFunc)
+}
+
+// Mux is a Router backed by fastrouter
+type Mux struct {
+	tree        *tree                             // registrations, shared with inline muxes
+	parent      *Mux                              // enclosing mux of an inline mux, nil otherwise
+	middlewares []func(http.Handler) http.Handler // stack added with Use
+}
+
+// tree holds the registrations of a mux and its inline muxes
+type tree struct {
+	mu               sync.Mutex
+	entries          []entry
+	notFound         http.Handler
+	methodNotAllowed http.Handler
+
+	once  sync.Once
+	table *dispatch.Table
+	miss  http.Handler // notFound wrapped in the root middlewares
+	deny  http.Handler // methodNotAllowed wrapped in the root middlewares
+}
+
+// entry is one registration. Mounted sub-routers are kept as entries and
+// grafted under their pattern when the tree is built.
+type entry struct {
+	method  string // empty for every method
+	pattern string
+	handler http.Handler
+	mount   *Mux                              // mounted sub-router, instead of handler
+	chain   []func(http.Handler) http.Handler // middlewares of the registering mux, outermost first
+}
+
+var _ Router = (*Mux)(nil)
+
+// NewRouter returns a new Mux
+func NewRouter() *Mux {
+	return NewMux()
+}
+
+// NewMux returns a new Mux
+func NewMux() *Mux {
+	return &Mux{tree: &tree{}}
+}
+
+// URLParam returns the url parameter from a http.Request object
+func URLParam(r *http.Request, key string) string {
+	return URLParamFromCtx(r.Context(), key)
+}
+
+// URLParamFromCtx returns the url parameter from a http.Request Context
+func URLParamFromCtx(ctx context.Context, key string) string {
+	return fastrouter.PathParamsFromContext(ctx)[key]
+}
+
+// Use appends middlewares to the mux stack. As in chi, middlewares of a
+// router must be defined before its routes.
+func (mx *Mux) Use(middlewares ...func(http.Handler) http.Handler) {
+	if mx.parent == nil {
+		mx.tree.mu.Lock()
+		routed := len(mx.tree.entries) > 0
+		mx.tree.mu.Unlock()
+		if routed {
+			panic("chi: all middlewares must be defined before routes on a mux")
+		}
+	}
+	mx.middlewares = append(mx.middlewares, middlewares...)
+}
+
+// With returns an inline mux that adds middlewares to the routes registered
+// on it
+func (mx *Mux) With(middlewares ...func(http.Handler) http.Handler) Router {
+	return &Mux{
+		tree:        mx.tree,
+		parent:      mx,
+		middlewares: append([]func(http.Handler) http.Handler(nil), middlewares...),
+	}
+}
+
+// Group calls fn with an inline mux, so middlewares it adds apply only to the
+// routes it registers
+func (mx *Mux) Group(fn func(r Router)) Router {
+	im := mx.With()
+	if fn != nil {
+		fn(im)
+	}
+	return im
+}
+
+// Route creates a sub-router, lets fn register its routes and mounts it along
+// pattern
+func (mx *Mux) Route(pattern string, fn func(r Router)) Router {
+	if fn == nil {
+		panic("chi: attempting to Route() a nil subrouter on '" + pattern + "'")
+	}
+	sub := NewRouter()
+	fn(sub)
+	mx.Mount(pattern, sub)
+	return sub
+}
+
+// Mount attaches h along pattern. A *Mux has its routes grafted under pattern;
+// any other handler serves pattern and everything below it, with the request
+// path left unchanged.
+func (mx *Mux) Mount(pattern string, h http.Handler) {
+	if h == nil {
+		panic("chi: attempting to Mount() a nil handler on '" + pattern + "'")
+	}
+	pattern = strings.TrimSuffix(pattern, "/")
+
+	if sub, ok := h.(*Mux); ok {
+		mx.add(entry{pattern: pattern, mount: sub})
+		return
+	}
+	for _, p := range []string{pattern, pattern + "/", pattern + "/*"} {
+		if p != "" {
+			mx.handle("", p, h)
+		}
+	}
+}
+
+// Handle adds a route for pattern matching every method
+func (mx *Mux) Handle(pattern string, h http.Handler) {
+	mx.handle("", pattern, h)
+}
+
+// HandleFunc adds a route for pattern matching every method
+func (mx *Mux) HandleFunc(pattern string, h http.HandlerFunc) {
+	mx.handle("", pattern, h)
+}
+
+// Method adds a route for pattern matching method
+func (mx *Mux) Method(method, pattern string, h http.Handler) {
+	mx.handle(strings.ToUpper(method), pattern, h)
+}
+
+// MethodFunc adds a route for pattern matching method
+func (mx *Mux) MethodFunc(method, pattern string, h http.HandlerFunc) {
+	mx.Method(method, pattern, h)
+}
+
+// Connect adds a route for pattern matching the CONNECT method
+func (mx *Mux) Connect(pattern string, h http.HandlerFunc) {
+	mx.handle(http.MethodConnect, pattern, h)
+}
+
+// Delete adds a route for pattern matching the DELETE method
+func (mx *Mux) Delete(pattern string, h http.HandlerFunc) {
+	mx.handle(http.MethodDelete, pattern, h)
+}
+
+// Get adds a route for pattern matching the GET method
+func (mx *Mux) Get(pattern string, h http.HandlerFunc) {
+	mx.handle(http.MethodGet, pattern, h)
+}
+
+// Head adds a route for pattern matching the HEAD method
+func (mx *Mux) Head(pattern string, h http.HandlerFunc) {
+	mx.handle(http.MethodHead, pattern, h)
+}
+
+// Options adds a route for pattern matching the OPTIONS method
+func (mx *Mux) Options(pattern string, h http.HandlerFunc) {
+	mx.handle(http.MethodOptions, pattern, h)
+}
+
+// Patch adds a route for pattern matching the PATCH method
+func (mx *Mux) Patch(pattern string, h http.HandlerFunc) {
+	mx.handle(http.MethodPatch, pattern, h)
+}
+
+// Post adds a route for pattern matching the POST method
+func (mx *Mux) Post(pattern string, h http.HandlerFunc) {
+	mx.handle(http.MethodPost, pattern, h)
+}
+
+// Put adds a route for pattern matching the PUT method
+func (mx *Mux) Put(pattern string, h http.HandlerFunc) {
+	mx.handle(http.MethodPut, pattern, h)
+}
+
+// Trace adds a route for pattern matching the TRACE method
+func (mx *Mux) Trace(pattern string, h http.HandlerFunc) {
+	mx.handle(http.MethodTrace, pattern, h)
+}
+
+// NotFound sets the handler for requests no route matches
+func (mx *Mux) NotFound(h http.HandlerFunc) {
+	mx.tree.mu.Lock()
+	mx.tree.notFound = h
+	mx.tree.mu.Unlock()
+}
+
+// MethodNotAllowed sets the handler for requests whose path has routes for
+// other methods only. The Allow header is set before it is called.
+func (mx *Mux) MethodNotAllowed(h http.HandlerFunc) {
+	mx.tree.mu.Lock()
+	mx.tree.methodNotAllowed = h
+	mx.tree.mu.Unlock()
+}
+
+// handle validates pattern and records a route
+func (mx *Mux) handle(method, pattern string, h http.Handler) {
+	if _, err := dispatch.Parse(pattern); err != nil {
+		panic("chi: " + err.Error())
+	}
+	mx.add(entry{method: method, pattern: pattern, handler: h})
+}
+
+// add records e with the middleware chain of mx
+func (mx *Mux) add(e entry) {
+	e.chain = mx.chain()
+
+	mx.tree.mu.Lock()
+	defer mx.tree.mu.Unlock()
+	if mx.tree.table != nil {
+		panic("chi: cannot register '" + e.pattern + "' after the router has started serving")
+	}
+	mx.tree.entries = append(mx.tree.entries, e)
+}
+
+// chain returns the middlewares of mx and its enclosing muxes, outermost first
+func (mx *Mux) chain() []func(http.Handler) http.Handler {
+	if mx.parent == nil {
+		return nil
+	}
+	return append(mx.parent.chain(), mx.middlewares...)
+}
+
+// root returns the mux owning the tree of an inline mux
+func (mx *Mux) root() *Mux {
+	for mx.parent != nil {
+		mx = mx.parent
+	}
+	return mx
+}
+
+// ServeHTTP routes the request. The router is built on the first call.
+func (mx *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	t := mx.root().build()
+
+	if handler, req := t.table.Lookup(r); handler != nil {
+		handler.ServeHTTP(w, req)
+		return
+	}
+	if allowed := t.table.AllowedMethods(r); len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		t.deny.ServeHTTP(w, r)
+		return
+	}
+	t.miss.ServeHTTP(w, r)
+}
+
+// build grafts mounted sub-routers, wraps every handler in its middlewares
+// and builds the dispatch table, once
+func (mx *Mux) build() *tree {
+	t := mx.tree
+	t.once.Do(func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		var routes []*dispatch.Route
+		index := make(map[string]int)
+		t.flatten("", mx.middlewares, &routes, index)
+
+		// chi tries regexp-constrained params before plain ones
+		sort.SliceStable(routes, func(i, j int) bool {
+			return routes[i].Pattern.HasRegexp() && !routes[j].Pattern.HasRegexp()
+		})
+
+		table, err := dispatch.Build(routes)
+		if err != nil {
+			panic("chi: " + err.Error())
+		}
+		t.table = table
+
+		t.miss, t.deny = t.notFound, t.methodNotAllowed
+		if t.miss == nil {
+			t.miss = http.HandlerFunc(http.NotFound)
+		}
+		if t.deny == nil {
+			t.deny = http.HandlerFunc(methodNotAllowed)
+		}
+		t.miss = wrap(mx.middlewares, t.miss)
+		t.deny = wrap(mx.middlewares, t.deny)
+	})
+	return t
+}
+
+// flatten appends the routes of t under prefix, wrapped in outer and then
+// their own middlewares. A later registration of the same method and pattern
+// replaces an earlier one, as in chi.
+func (t *tree) flatten(prefix string, outer []func(http.Handler) http.Handler, routes *[]*dispatch.Route, index map[string]int) {
+	for _, e := range t.entries {
+		chain := append(append([]func(http.Handler) http.Handler(nil), outer...), e.chain...)
+
+		if e.mount != nil {
+			sub := e.mount.tree
+			sub.mu.Lock()
+			sub.flatten(prefix+e.pattern, append(chain, e.mount.middlewares...), routes, index)
+			sub.mu.Unlock()
+			continue
+		}
+
+		handler := wrap(chain, e.handler)
+		methods := []string{e.method}
+		if e.method == "" {
+			methods = dispatch.Methods
+		}
+
+		// A sub-router's "/" route also answers the mount point itself
+		paths := []string{prefix + e.pattern}
+		if prefix != "" && e.pattern == "/" {
+			paths = []string{prefix, prefix + "/"}
+		}
+
+		for _, path := range paths {
+			pattern, err := dispatch.Parse(path)
+			if err != nil {
+				panic("chi: " + err.Error())
+			}
+			for _, method := range methods {
+				rt := &dispatch.Route{Method: method, Pattern: pattern, Handler: handler}
+				key := method + " " + path
+				if i, exists := index[key]; exists {
+					(*routes)[i] = rt
+					continue
+				}
+				index[key] = len(*routes)
+				*routes = append(*routes, rt)
+			}
+		}
+	}
+}
+
+// wrap applies middlewares to h so the first one runs first
+func wrap(middlewares []func(http.Handler) http.Handler, h http.Handler) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusMethodNotAllowed)
+}
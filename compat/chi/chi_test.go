@@ -0,0 +1,186 @@
+package chi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// reply writes name followed by the requested URL params
+func reply(name string, keys ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body := name
+		for _, key := range keys {
+			body += " " + key + "=" + URLParam(r, key)
+		}
+		w.Write([]byte(body))
+	}
+}
+
+// tag is a middleware that appends its name to the X-Middleware header
+func tag(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Middleware", name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type conformanceCase struct {
+	method, path string
+	status       int
+	body         string
+	middleware   string
+}
+
+func runConformance(t *testing.T, router http.Handler, cases []conformanceCase) {
+	t.Helper()
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		if w.Code != tc.status {
+			t.Errorf("%s %s: expected status %d, got %d", tc.method, tc.path, tc.status, w.Code)
+			continue
+		}
+		if tc.body != "" && w.Body.String() != tc.body {
+			t.Errorf("%s %s: expected body '%s', got '%s'", tc.method, tc.path, tc.body, w.Body.String())
+		}
+		if tc.middleware == "" {
+			continue
+		}
+		// The innermost middleware adds the last value
+		if got := w.Header().Values("X-Middleware"); len(got) == 0 || got[len(got)-1] != tc.middleware {
+			t.Errorf("%s %s: expected middleware '%s', got %v", tc.method, tc.path, tc.middleware, got)
+		}
+	}
+}
+
+// TestREADMEExample replays the REST example from chi's README
+func TestREADMEExample(t *testing.T) {
+	r := NewRouter()
+	r.Use(tag("logger"))
+
+	r.Get("/", reply("welcome"))
+
+	r.Route("/articles", func(r Router) {
+		r.With(tag("paginate")).Get("/", reply("listArticles"))
+		r.Post("/", reply("createArticle"))
+		r.Get("/search", reply("searchArticles"))
+		r.Get("/{articleSlug:[a-z-]+}", reply("getArticleBySlug", "articleSlug"))
+
+		r.Route("/{articleID}", func(r Router) {
+			r.Use(tag("articleCtx"))
+			r.Get("/", reply("getArticle", "articleID"))
+			r.Put("/", reply("updateArticle", "articleID"))
+			r.Delete("/", reply("deleteArticle", "articleID"))
+		})
+	})
+
+	admin := NewRouter()
+	admin.Use(tag("adminOnly"))
+	admin.Get("/", reply("adminIndex"))
+	admin.Get("/accounts", reply("adminListAccounts"))
+	r.Mount("/admin", admin)
+
+	runConformance(t, r, []conformanceCase{
+		{"GET", "/", 200, "welcome", "logger"},
+		{"GET", "/articles", 200, "listArticles", "paginate"},
+		{"GET", "/articles/", 200, "listArticles", "paginate"},
+		{"POST", "/articles", 200, "createArticle", "logger"},
+		{"GET", "/articles/search", 200, "searchArticles", "logger"},
+		{"GET", "/articles/hello-world", 200, "getArticleBySlug articleSlug=hello-world", "logger"},
+		{"GET", "/articles/123", 200, "getArticle articleID=123", "articleCtx"},
+		{"PUT", "/articles/123/", 200, "updateArticle articleID=123", "articleCtx"},
+		{"DELETE", "/articles/123", 200, "deleteArticle articleID=123", "articleCtx"},
+		{"GET", "/admin", 200, "adminIndex", "adminOnly"},
+		{"GET", "/admin/accounts", 200, "adminListAccounts", "adminOnly"},
+		{"GET", "/missing", 404, "", "logger"},
+		{"PATCH", "/articles/123", 405, "", "logger"},
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("PATCH", "/articles/123", nil))
+	if allow := w.Header().Get("Allow"); allow != "DELETE, GET, PUT" {
+		t.Errorf("Expected Allow 'DELETE, GET, PUT', got '%s'", allow)
+	}
+}
+
+// TestURLPatterns replays the routing pattern examples from chi's docs
+func TestURLPatterns(t *testing.T) {
+	r := NewRouter()
+	r.Get("/user/{userID}", reply("user", "userID"))
+	r.Get("/page/*", reply("page", "*"))
+	r.Get("/date/{yyyy:\\d\\d\\d\\d}/{mm:\\d\\d}/{dd:\\d\\d}", reply("date", "yyyy", "mm", "dd"))
+	r.Get("/articles/{rid:^[0-9]{5,6}}", reply("article", "rid"))
+	r.Get("/files/{id:[0-9]+}", reply("file", "id"))
+	r.Get("/files/*", reply("files", "*"))
+
+	runConformance(t, r, []conformanceCase{
+		{"GET", "/user/jsmith", 200, "user userID=jsmith", ""},
+		{"GET", "/page/intro/latest", 200, "page *=intro/latest", ""},
+		{"GET", "/date/2017/04/01", 200, "date yyyy=2017 mm=04 dd=01", ""},
+		{"GET", "/date/17/04/01", 404, "", ""},
+		{"GET", "/articles/12345", 200, "article rid=12345", ""},
+		{"GET", "/articles/1234", 404, "", ""},
+		{"GET", "/files/42", 200, "file id=42", ""},
+		// A rejected constraint falls through to the catch-all
+		{"GET", "/files/abc", 200, "files *=abc", ""},
+	})
+}
+
+func TestGroupsAndHandlers(t *testing.T) {
+	r := NewRouter()
+	r.Group(func(r Router) {
+		r.Use(tag("auth"))
+		r.Get("/private", reply("private"))
+	})
+	r.Get("/public", reply("public"))
+	r.Handle("/any", reply("any"))
+	r.MethodFunc("post", "/method", reply("method"))
+	r.Mount("/static", reply("static"))
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+	})
+
+	runConformance(t, r, []conformanceCase{
+		{"GET", "/private", 200, "private", "auth"},
+		{"DELETE", "/any", 200, "any", ""},
+		{"POST", "/method", 200, "method", ""},
+		{"GET", "/static/css/site.css", 200, "static", ""},
+		{"GET", "/static", 200, "static", ""},
+		{"GET", "/nowhere", 418, "", ""},
+		{"GET", "/method", 409, "", ""},
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/public", nil))
+	if got := w.Header().Get("X-Middleware"); got != "" {
+		t.Errorf("Expected group middleware to skip /public, got '%s'", got)
+	}
+}
+
+func TestLateRegistrationPanics(t *testing.T) {
+	r := NewRouter()
+	r.Get("/", reply("home"))
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected Use after routes to panic")
+			}
+		}()
+		r.Use(tag("late"))
+	}()
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected registration after serving to panic")
+		}
+	}()
+	r.Get("/late", reply("late"))
+}
@@ -0,0 +1,388 @@
+// Package httprouter is a drop-in replacement for github.com/julienschmidt/httprouter
+// backed by fastrouter. Services migrate by swapping the import path:
+//
+//	router := httprouter.New()
+//	router.GET("/users/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+//		fmt.Fprintf(w, "user %s", ps.ByName("id"))
+//	})
+//	log.Fatal(http.ListenAndServe(":8080", router))
+//
+// Routes may be registered in any order. They are collected, sorted and built
+// into an immutable fastrouter.Router on the first request; registering a route
+// after that panics.
+package httprouter
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jamra/fastrouter"
+)
+
+// Handle is a function that can be registered to a route to handle HTTP
+// requests. Like http.HandlerFunc, but has a third parameter for the values of
+// wildcards (path variables).
+type Handle func(http.ResponseWriter, *http.Request, Params)
+
+// Param is a single URL parameter, consisting of a key and a value
+type Param struct {
+	Key   string
+	Value string
+}
+
+// Params is a Param-slice, as returned by the router. The slice is ordered:
+// the first URL parameter is also the first slice value.
+type Params []Param
+
+// ByName returns the value of the first Param whose key matches the given
+// name, or an empty string if there is none
+func (ps Params) ByName(name string) string {
+	for _, p := range ps {
+		if p.Key == name {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+type paramsKey struct{}
+
+// ParamsKey is the request context key under which URL params are stored for
+// handlers registered with Handler or HandlerFunc
+var ParamsKey = paramsKey{}
+
+// ParamsFromContext pulls the URL parameters from a request context, or
+// returns nil if none are present
+func ParamsFromContext(ctx context.Context) Params {
+	p, _ := ctx.Value(ParamsKey).(Params)
+	return p
+}
+
+// Router is an http.Handler which dispatches requests to different handler
+// functions via configurable routes
+type Router struct {
+	// RedirectTrailingSlash and RedirectFixedPath are accepted for source
+	// compatibility but NOT implemented: fastrouter matches paths exactly and
+	// never redirects, so New leaves both false. Code that relies on
+	// httprouter's redirects gets 404 for the uncorrected path instead.
+	RedirectTrailingSlash bool
+	RedirectFixedPath     bool
+
+	// If enabled, the router answers a request whose path matches a route
+	// registered for another method with 405 Method Not Allowed and an Allow
+	// header, instead of 404
+	HandleMethodNotAllowed bool
+
+	// If enabled, the router automatically replies to OPTIONS requests.
+	// Custom OPTIONS handlers take priority over automatic replies.
+	HandleOPTIONS bool
+
+	// An optional http.Handler that is called on automatic OPTIONS requests,
+	// after the Allow header has been set
+	GlobalOPTIONS http.Handler
+
+	// Configurable http.Handler which is called when no matching route is
+	// found. If it is not set, http.NotFound is used.
+	NotFound http.Handler
+
+	// Configurable http.Handler which is called when a request cannot be
+	// routed and HandleMethodNotAllowed is true. If it is not set, http.Error
+	// with http.StatusMethodNotAllowed is used. The Allow header is set first.
+	MethodNotAllowed http.Handler
+
+	// Function to handle panics recovered from http handlers. It should be
+	// used to generate an error page and return the http error code 500.
+	// The handler can be used to keep your server from crashing because of
+	// unrecovered panics.
+	PanicHandler func(http.ResponseWriter, *http.Request, interface{})
+
+	mu     sync.Mutex
+	routes []route
+	seen   map[string]bool
+	once   sync.Once
+	router *fastrouter.Router
+	err    error // why the build failed, reported by every later call
+}
+
+// route is a registered Handle together with the names of its parameters, in
+// path order, so Params can be rebuilt in the order httprouter reports them
+type route struct {
+	method string
+	path   string
+	handle Handle
+	names  []string
+	wild   string // name of the catch-all parameter, if any
+}
+
+// ServeHTTPParams implements fastrouter.ParamsHandler
+func (rt *route) ServeHTTPParams(w http.ResponseWriter, r *http.Request, params fastrouter.PathParams) {
+	rt.handle(w, r, rt.params(params))
+}
+
+// ServeHTTP implements http.Handler, reading params from the request context
+func (rt *route) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt.ServeHTTPParams(w, r, fastrouter.GetPathParams(r))
+}
+
+// params converts fastrouter's params into ordered httprouter Params. As in
+// httprouter, a catch-all value keeps its leading slash.
+func (rt *route) params(params fastrouter.PathParams) Params {
+	if len(rt.names) == 0 {
+		return nil
+	}
+
+	ps := make(Params, len(rt.names))
+	for i, name := range rt.names {
+		value := params[name]
+		if name == rt.wild {
+			value = "/" + value
+		}
+		ps[i] = Param{Key: name, Value: value}
+	}
+	return ps
+}
+
+// New returns a new initialized Router. Path auto-correction is not
+// supported, so unlike httprouter RedirectTrailingSlash and RedirectFixedPath
+// are false; method-not-allowed and OPTIONS handling are enabled by default.
+func New() *Router {
+	return &Router{
+		HandleMethodNotAllowed: true,
+		HandleOPTIONS:          true,
+	}
+}
+
+// GET is a shortcut for router.Handle(http.MethodGet, path, handle)
+func (r *Router) GET(path string, handle Handle) {
+	r.Handle(http.MethodGet, path, handle)
+}
+
+// HEAD is a shortcut for router.Handle(http.MethodHead, path, handle)
+func (r *Router) HEAD(path string, handle Handle) {
+	r.Handle(http.MethodHead, path, handle)
+}
+
+// OPTIONS is a shortcut for router.Handle(http.MethodOptions, path, handle)
+func (r *Router) OPTIONS(path string, handle Handle) {
+	r.Handle(http.MethodOptions, path, handle)
+}
+
+// POST is a shortcut for router.Handle(http.MethodPost, path, handle)
+func (r *Router) POST(path string, handle Handle) {
+	r.Handle(http.MethodPost, path, handle)
+}
+
+// PUT is a shortcut for router.Handle(http.MethodPut, path, handle)
+func (r *Router) PUT(path string, handle Handle) {
+	r.Handle(http.MethodPut, path, handle)
+}
+
+// PATCH is a shortcut for router.Handle(http.MethodPatch, path, handle)
+func (r *Router) PATCH(path string, handle Handle) {
+	r.Handle(http.MethodPatch, path, handle)
+}
+
+// DELETE is a shortcut for router.Handle(http.MethodDelete, path, handle)
+func (r *Router) DELETE(path string, handle Handle) {
+	r.Handle(http.MethodDelete, path, handle)
+}
+
+// Handle registers a new request handle with the given path and method.
+// Like httprouter, it panics on an invalid or duplicate registration, and
+// also when called after the router has served its first request.
+func (r *Router) Handle(method, path string, handle Handle) {
+	if method == "" {
+		panic("method must not be empty")
+	}
+	if len(path) < 1 || path[0] != '/' {
+		panic("path must begin with '/' in path '" + path + "'")
+	}
+	if handle == nil {
+		panic("handle must not be nil")
+	}
+
+	rt := route{method: method, path: path, handle: handle}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if segment == "" {
+			continue
+		}
+		switch segment[0] {
+		case ':':
+			rt.names = append(rt.names, segment[1:])
+		case '*':
+			if i != len(segments)-1 {
+				panic("catch-all routes are only allowed at the end of the path in path '" + path + "'")
+			}
+			rt.wild = segment[1:]
+			if rt.wild == "" {
+				panic("catch-all must be named with a non-empty name in path '" + path + "'")
+			}
+			rt.names = append(rt.names, rt.wild)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.router != nil {
+		panic("cannot register route '" + path + "' after the router has started serving")
+	}
+	if r.seen == nil {
+		r.seen = make(map[string]bool)
+	}
+	key := method + " " + path
+	if r.seen[key] {
+		panic("a handle is already registered for path '" + path + "'")
+	}
+	r.seen[key] = true
+	r.routes = append(r.routes, rt)
+}
+
+// Handler is an adapter which allows the usage of an http.Handler as a
+// request handle. The Params are available in the request context under
+// ParamsKey.
+func (r *Router) Handler(method, path string, handler http.Handler) {
+	r.Handle(method, path,
+		func(w http.ResponseWriter, req *http.Request, p Params) {
+			if len(p) > 0 {
+				req = req.WithContext(context.WithValue(req.Context(), ParamsKey, p))
+			}
+			handler.ServeHTTP(w, req)
+		},
+	)
+}
+
+// HandlerFunc is an adapter which allows the usage of an http.HandlerFunc as a
+// request handle
+func (r *Router) HandlerFunc(method, path string, handler http.HandlerFunc) {
+	r.Handler(method, path, handler)
+}
+
+// ServeFiles serves files from the given file system root. The path must end
+// with "/*filepath", files are then served from the local path
+// /defined/root/dir/*filepath. For example if root is "/etc" and *filepath is
+// "passwd", the local file "/etc/passwd" would be served. Internally a
+// http.FileServer is used, therefore http.NotFound is used instead of the
+// Router's NotFound handler.
+//
+//	router.ServeFiles("/src/*filepath", http.Dir("/var/www"))
+func (r *Router) ServeFiles(path string, root http.FileSystem) {
+	if len(path) < 10 || path[len(path)-10:] != "/*filepath" {
+		panic("path must end with /*filepath in path '" + path + "'")
+	}
+
+	fileServer := http.FileServer(root)
+
+	r.GET(path, func(w http.ResponseWriter, req *http.Request, ps Params) {
+		req.URL.Path = ps.ByName("filepath")
+		fileServer.ServeHTTP(w, req)
+	})
+}
+
+// Lookup allows the manual lookup of a method + path combo. If the path was
+// found, it returns the handle function and the path parameter values. The
+// third return value is always false: fastrouter does not suggest trailing
+// slash redirects.
+func (r *Router) Lookup(method, path string) (Handle, Params, bool) {
+	handler, params := r.build().Match(method, path)
+	if handler == nil {
+		return nil, nil, false
+	}
+	defer fastrouter.ReleaseParams(params)
+
+	rt := handler.(*route)
+	return rt.handle, rt.params(params), false
+}
+
+// build sorts the registered routes and builds the fastrouter.Router once.
+// A failed build panics on this and every later call.
+func (r *Router) build() *fastrouter.Router {
+	r.once.Do(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		sort.SliceStable(r.routes, func(i, j int) bool {
+			return r.routes[i].path < r.routes[j].path
+		})
+
+		rb := fastrouter.NewRouterBuilder()
+		for i := range r.routes {
+			rt := &r.routes[i]
+			if r.err = rb.AddRoute(rt.method, rt.path, rt); r.err != nil {
+				return
+			}
+		}
+		r.router, r.err = rb.Build()
+	})
+	if r.err != nil {
+		panic(r.err)
+	}
+	return r.router
+}
+
+func (r *Router) recv(w http.ResponseWriter, req *http.Request) {
+	if rcv := recover(); rcv != nil {
+		r.PanicHandler(w, req, rcv)
+	}
+}
+
+// ServeHTTP makes the router implement the http.Handler interface. The
+// router is built from the registered routes on the first call.
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if r.PanicHandler != nil {
+		defer r.recv(w, req)
+	}
+
+	router := r.build()
+	path := req.URL.Path
+
+	if handler, params := router.Match(req.Method, path); handler != nil {
+		handler.(*route).ServeHTTPParams(w, req, params)
+		fastrouter.ReleaseParams(params)
+		return
+	}
+
+	if req.Method == http.MethodOptions && r.HandleOPTIONS {
+		if allowed := router.AllowedMethods(path); len(allowed) > 0 {
+			w.Header().Set("Allow", allowHeader(allowed))
+			if r.GlobalOPTIONS != nil {
+				r.GlobalOPTIONS.ServeHTTP(w, req)
+			}
+			return
+		}
+	} else if r.HandleMethodNotAllowed {
+		if allowed := router.AllowedMethods(path); len(allowed) > 0 {
+			w.Header().Set("Allow", allowHeader(allowed))
+			if r.MethodNotAllowed != nil {
+				r.MethodNotAllowed.ServeHTTP(w, req)
+			} else {
+				http.Error(w,
+					http.StatusText(http.StatusMethodNotAllowed),
+					http.StatusMethodNotAllowed,
+				)
+			}
+			return
+		}
+	}
+
+	if r.NotFound != nil {
+		r.NotFound.ServeHTTP(w, req)
+	} else {
+		http.NotFound(w, req)
+	}
+}
+
+// allowHeader formats allowed methods for the Allow header, adding OPTIONS as
+// httprouter does
+func allowHeader(allowed []string) string {
+	for _, method := range allowed {
+		if method == http.MethodOptions {
+			return strings.Join(allowed, ", ")
+		}
+	}
+	return strings.Join(append(allowed, http.MethodOptions), ", ")
+}
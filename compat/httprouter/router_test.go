@@ -0,0 +1,214 @@
+package httprouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRouterParams(t *testing.T) {
+	router := New()
+
+	var got Params
+	capture := func(w http.ResponseWriter, r *http.Request, ps Params) {
+		got = ps
+	}
+
+	// Registration order does not matter: routes are sorted on first request
+	router.GET("/users/:id/posts/:postId", capture)
+	router.GET("/src/*filepath", capture)
+	router.GET("/users/:id", capture)
+
+	for _, tc := range []struct {
+		path     string
+		expected Params
+	}{
+		{"/users/42", Params{{"id", "42"}}},
+		{"/users/42/posts/7", Params{{"id", "42"}, {"postId", "7"}}},
+		{"/src/a/b.txt", Params{{"filepath", "/a/b.txt"}}},
+		{"/src/", Params{{"filepath", "/"}}},
+	} {
+		got = nil
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", tc.path, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200 for %s, got %d", tc.path, w.Code)
+			continue
+		}
+		if len(got) != len(tc.expected) {
+			t.Errorf("Expected params %v for %s, got %v", tc.expected, tc.path, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.expected[i] {
+				t.Errorf("Expected params %v for %s, got %v", tc.expected, tc.path, got)
+				break
+			}
+		}
+	}
+
+	if got := (Params{{"id", "42"}}).ByName("missing"); got != "" {
+		t.Errorf("Expected empty ByName for missing key, got '%s'", got)
+	}
+}
+
+func TestRouterHandler(t *testing.T) {
+	router := New()
+	router.Handler("GET", "/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("user:" + ParamsFromContext(r.Context()).ByName("id")))
+	}))
+	router.HandlerFunc("POST", "/users", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("created"))
+	})
+
+	for _, tc := range []struct{ method, path, expected string }{
+		{"GET", "/users/42", "user:42"},
+		{"POST", "/users", "created"},
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		if w.Body.String() != tc.expected {
+			t.Errorf("Expected response '%s' for %s %s, got '%s'", tc.expected, tc.method, tc.path, w.Body.String())
+		}
+	}
+
+	handle, ps, tsr := router.Lookup("GET", "/users/7")
+	if handle == nil || ps.ByName("id") != "7" || tsr {
+		t.Errorf("Expected Lookup to find /users/:id with id=7, got %v %v", ps, tsr)
+	}
+}
+
+func TestRouterNotFoundAndMethodNotAllowed(t *testing.T) {
+	router := New()
+	router.GET("/users/:id", func(w http.ResponseWriter, r *http.Request, ps Params) {})
+	router.PUT("/users/:id", func(w http.ResponseWriter, r *http.Request, ps Params) {})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/users/42", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", w.Code)
+	}
+	if allow := w.Header().Get("Allow"); allow != "GET, PUT, OPTIONS" {
+		t.Errorf("Expected Allow 'GET, PUT, OPTIONS', got '%s'", allow)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("OPTIONS", "/users/42", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Allow") != "GET, PUT, OPTIONS" {
+		t.Errorf("Expected automatic OPTIONS reply, got %d with Allow '%s'", w.Code, w.Header().Get("Allow"))
+	}
+
+	router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/missing", nil))
+	if w.Code != http.StatusTeapot {
+		t.Errorf("Expected custom NotFound status 418, got %d", w.Code)
+	}
+
+	router.HandleMethodNotAllowed = false
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/users/42", nil))
+	if w.Code != http.StatusTeapot {
+		t.Errorf("Expected NotFound when HandleMethodNotAllowed is off, got %d", w.Code)
+	}
+}
+
+func TestRouterPanicHandler(t *testing.T) {
+	router := New()
+	router.GET("/panic", func(w http.ResponseWriter, r *http.Request, ps Params) {
+		panic("oops")
+	})
+
+	var recovered interface{}
+	router.PanicHandler = func(w http.ResponseWriter, r *http.Request, rcv interface{}) {
+		recovered = rcv
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/panic", nil))
+	if recovered != "oops" || w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected PanicHandler to recover 'oops' with 500, got %v with %d", recovered, w.Code)
+	}
+}
+
+func TestRouterServeFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"css/site.css": {Data: []byte("body{}")},
+	}
+
+	router := New()
+	router.ServeFiles("/static/*filepath", http.FS(fsys))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/static/css/site.css", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "body{}" {
+		t.Errorf("Expected file contents with 200, got %d '%s'", w.Code, w.Body.String())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected ServeFiles to panic without /*filepath")
+		}
+	}()
+	router.ServeFiles("/assets/*", http.FS(fsys))
+}
+
+func TestRouterRegistrationPanics(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		register func(r *Router)
+		message  string
+	}{
+		{"relative path", func(r *Router) { r.GET("users", nil) }, "must begin with '/'"},
+		{"duplicate", func(r *Router) {
+			h := func(w http.ResponseWriter, r *http.Request, ps Params) {}
+			r.GET("/users", h)
+			r.GET("/users", h)
+		}, "already registered"},
+		{"after serving", func(r *Router) {
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+			r.GET("/late", func(w http.ResponseWriter, r *http.Request, ps Params) {})
+		}, "after the router has started serving"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				rcv := recover()
+				msg, _ := rcv.(string)
+				if !strings.Contains(msg, tc.message) {
+					t.Errorf("Expected panic containing '%s', got %v", tc.message, rcv)
+				}
+			}()
+			tc.register(New())
+		})
+	}
+}
+
+func TestRouterBuildFailurePanicsEveryCall(t *testing.T) {
+	r := New()
+	r.GET("/files/a{b}", func(w http.ResponseWriter, r *http.Request, ps Params) {})
+
+	// The build runs once, so a later request must not find a nil router
+	for i := 0; i < 2; i++ {
+		func() {
+			defer func() {
+				err, _ := recover().(error)
+				if err == nil || !strings.Contains(err.Error(), "must span a whole segment") {
+					t.Errorf("Call %d: expected the build error, got %v", i+1, err)
+				}
+			}()
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/files/x", nil))
+		}()
+	}
+}
+
+func TestNewDoesNotPromiseRedirects(t *testing.T) {
+	r := New()
+	if r.RedirectTrailingSlash || r.RedirectFixedPath {
+		t.Error("Expected the unimplemented redirects to default to false")
+	}
+}
@@ -0,0 +1,323 @@
+// Package dispatch maps the path templates of other routers onto fastrouter.
+//
+// Templates such as "/articles/{id:[0-9]+}" are rewritten with positional
+// parameter names ("/articles/:p0"), so routes that differ only in variable
+// names or regexp constraints share one fastrouter route. fastrouter matches
+// the shape; the Table then tries each candidate registered for that shape in
+// order and serves the first whose constraints accept the request. When none
+// does, the other shapes of the method that match the path are tried, most
+// specific first, as the original routers fall through to later routes.
+package dispatch
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jamra/fastrouter"
+)
+
+// Methods are the methods a route registered for "any method" is added under
+var Methods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace,
+}
+
+// Pattern is a parsed path template
+type Pattern struct {
+	Path    string           // fastrouter path with positional parameter names
+	names   []string         // variable names, in path order
+	keys    []string         // fastrouter parameter names, parallel to names
+	regexps []*regexp.Regexp // constraints, parallel to names; nil entries match anything
+}
+
+// Parse converts a path template into a Pattern. Each {name} or {name:regexp}
+// must span a whole segment, and a trailing * captures the rest of the path
+// under the name "*".
+func Parse(tpl string) (Pattern, error) {
+	if !strings.HasPrefix(tpl, "/") {
+		return Pattern{}, fmt.Errorf("path template %q must start with '/'", tpl)
+	}
+
+	segments, err := splitSegments(tpl)
+	if err != nil {
+		return Pattern{}, err
+	}
+
+	var p Pattern
+	for i, segment := range segments {
+		switch {
+		case segment == "*":
+			if i != len(segments)-1 {
+				return Pattern{}, fmt.Errorf("path template %q: * must be the last segment", tpl)
+			}
+			p.names = append(p.names, "*")
+			p.keys = append(p.keys, "*")
+			p.regexps = append(p.regexps, nil)
+
+		case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"):
+			name, expr, _ := strings.Cut(segment[1:len(segment)-1], ":")
+			if name == "" {
+				return Pattern{}, fmt.Errorf("path template %q: missing variable name in %s", tpl, segment)
+			}
+
+			var re *regexp.Regexp
+			if expr != "" {
+				if re, err = regexp.Compile("^(?:" + expr + ")$"); err != nil {
+					return Pattern{}, fmt.Errorf("path template %q: %v", tpl, err)
+				}
+			}
+
+			key := "p" + strconv.Itoa(len(p.keys))
+			segments[i] = ":" + key
+			p.names = append(p.names, name)
+			p.keys = append(p.keys, key)
+			p.regexps = append(p.regexps, re)
+
+		case strings.ContainsAny(segment, "{}"):
+			return Pattern{}, fmt.Errorf("path template %q: variable in %q must span a whole segment", tpl, segment)
+
+		case strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*"):
+			return Pattern{}, fmt.Errorf("path template %q: literal segment %q would be read as a wildcard", tpl, segment)
+		}
+	}
+
+	p.Path = strings.Join(segments, "/")
+	return p, nil
+}
+
+// splitSegments splits tpl on the slashes outside of braces, so regexps such
+// as {id:[0-9]{3}} or {path:a/b} stay in one piece
+func splitSegments(tpl string) ([]string, error) {
+	var segments []string
+	depth, start := 0, 0
+	for i := 0; i < len(tpl); i++ {
+		switch tpl[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("path template %q: unbalanced braces", tpl)
+			}
+		case '/':
+			if depth == 0 {
+				segments = append(segments, tpl[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("path template %q: unbalanced braces", tpl)
+	}
+	return append(segments, tpl[start:]), nil
+}
+
+// HasRegexp reports whether any variable of p carries a constraint
+func (p Pattern) HasRegexp() bool {
+	for _, re := range p.regexps {
+		if re != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// vars returns the variables of p captured in params, or false if a
+// constraint rejects them
+func (p Pattern) vars(params fastrouter.PathParams) (map[string]string, bool) {
+	vars := make(map[string]string, len(p.names))
+	for i, name := range p.names {
+		value := params[p.keys[i]]
+		if re := p.regexps[i]; re != nil && !re.MatchString(value) {
+			return nil, false
+		}
+		vars[name] = value
+	}
+	return vars, true
+}
+
+// Route is one registration of an adapter
+type Route struct {
+	Method  string
+	Pattern Pattern
+	Handler http.Handler
+
+	// Accept optionally applies further conditions, such as a host template,
+	// and may add to vars. A nil Accept accepts every request.
+	Accept func(r *http.Request, vars map[string]string) bool
+}
+
+// candidates are the routes sharing one method and fastrouter path, in the
+// order they are tried
+type candidates struct {
+	routes   []*Route
+	segments []string      // the fastrouter path, split on "/"
+	others   []*candidates // the other shapes of the method, most specific first
+}
+
+// ServeHTTP satisfies http.Handler so candidates can be registered with
+// fastrouter; the Table dispatches them through Lookup instead
+func (c *candidates) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	http.NotFound(w, r)
+}
+
+// accept returns the handler of the first route accepting req with params
+func (c *candidates) accept(req *http.Request, params fastrouter.PathParams) (http.Handler, *http.Request) {
+	for _, rt := range c.routes {
+		vars, ok := rt.Pattern.vars(params)
+		if !ok {
+			continue
+		}
+		if rt.Accept != nil && !rt.Accept(req, vars) {
+			continue
+		}
+		return rt.Handler, fastrouter.WithPathParams(req, vars)
+	}
+	return nil, req
+}
+
+// match matches path against the shape alone, as fastrouter would if it were
+// the only route: static segments compare equal, a parameter takes one
+// segment and a trailing "*" the rest of the path
+func (c *candidates) match(path string) (fastrouter.PathParams, bool) {
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	params := make(fastrouter.PathParams)
+	for i, segment := range c.segments {
+		if segment == "*" {
+			if i >= len(parts) {
+				return nil, false
+			}
+			params["*"] = strings.Join(parts[i:], "/")
+			return params, true
+		}
+		if i >= len(parts) {
+			return nil, false
+		}
+		if strings.HasPrefix(segment, ":") {
+			params[segment[1:]] = parts[i]
+		} else if segment != parts[i] {
+			return nil, false
+		}
+	}
+	return params, len(parts) == len(c.segments)
+}
+
+// moreSpecific orders shapes segment by segment: static before parameter
+// before wildcard, as fastrouter prefers them
+func moreSpecific(a, b []string) bool {
+	rank := func(segment string) int {
+		switch {
+		case segment == "*":
+			return 2
+		case strings.HasPrefix(segment, ":"):
+			return 1
+		}
+		return 0
+	}
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if ra, rb := rank(a[i]), rank(b[i]); ra != rb {
+			return ra < rb
+		}
+	}
+	return len(a) > len(b)
+}
+
+// Table is the set of routes of one adapter, built into a fastrouter.Router
+type Table struct {
+	router *fastrouter.Router
+}
+
+// Build groups routes by method and fastrouter path, keeping their relative
+// order, and builds the router
+func Build(routes []*Route) (*Table, error) {
+	type key struct{ method, path string }
+	groups := make(map[key]*candidates)
+	byMethod := make(map[string][]*candidates)
+	var order []key
+	for _, rt := range routes {
+		k := key{rt.Method, rt.Pattern.Path}
+		group, exists := groups[k]
+		if !exists {
+			group = &candidates{segments: strings.Split(strings.TrimPrefix(k.path, "/"), "/")}
+			groups[k] = group
+			byMethod[k.method] = append(byMethod[k.method], group)
+			order = append(order, k)
+		}
+		group.routes = append(group.routes, rt)
+	}
+
+	for _, shapes := range byMethod {
+		sort.SliceStable(shapes, func(i, j int) bool {
+			return moreSpecific(shapes[i].segments, shapes[j].segments)
+		})
+		for _, group := range shapes {
+			for _, other := range shapes {
+				if other != group {
+					group.others = append(group.others, other)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return order[i].path < order[j].path
+	})
+
+	rb := fastrouter.NewRouterBuilder()
+	for _, k := range order {
+		if err := rb.AddRoute(k.method, k.path, groups[k]); err != nil {
+			return nil, err
+		}
+	}
+
+	router, err := rb.Build()
+	if err != nil {
+		return nil, err
+	}
+	return &Table{router: router}, nil
+}
+
+// Lookup returns the handler of the first route accepting req, and req with
+// the route's variables available through fastrouter.GetPathParams and
+// r.PathValue. When the constraints reject every route of the shape
+// fastrouter matched, the other shapes matching the path are tried in turn.
+// It returns a nil handler when no route accepts req.
+func (t *Table) Lookup(req *http.Request) (http.Handler, *http.Request) {
+	handler, params := t.router.Match(req.Method, req.URL.Path)
+	if handler == nil {
+		return nil, req
+	}
+	matched := handler.(*candidates)
+	h, r := matched.accept(req, params)
+	fastrouter.ReleaseParams(params)
+	if h != nil {
+		return h, r
+	}
+
+	// Only a constraint miss pays for matching the other shapes
+	for _, other := range matched.others {
+		if params, ok := other.match(req.URL.Path); ok {
+			if h, r := other.accept(req, params); h != nil {
+				return h, r
+			}
+		}
+	}
+	return nil, req
+}
+
+// AllowedMethods returns the methods with a route for the request path, for
+// the Allow header of a 405 response. It returns nil when req.Method itself has
+// a route there, since the miss then came from a constraint and is a 404.
+func (t *Table) AllowedMethods(req *http.Request) []string {
+	allowed := t.router.AllowedMethods(req.URL.Path)
+	for _, method := range allowed {
+		if method == req.Method {
+			return nil
+		}
+	}
+	return allowed
+}
@@ -0,0 +1,471 @@
+// Package mux translates gorilla/mux style registration onto fastrouter.
+// Services migrate by swapping the import path:
+//
+//	r := mux.NewRouter()
+//	r.HandleFunc("/articles/{category}/{id:[0-9]+}", ArticleHandler).Methods("GET")
+//	s := r.Host("{subdomain:[a-z]+}.example.com").Subrouter()
+//	s.HandleFunc("/products/{key}", ProductHandler)
+//	http.ListenAndServe(":8000", r)
+//
+// Path variables are read with Vars and must span a whole segment. Routes are
+// built into one fastrouter.Router on the first request; Host and regexp
+// constraints are checked after fastrouter matches the path.
+//
+// gorilla/mux tries routes in registration order. Here routes whose paths only
+// differ in variable names or constraints keep that order, but otherwise a
+// static segment wins over a variable, as in fastrouter.
+package mux
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jamra/fastrouter"
+	"github.com/jamra/fastrouter/compat/internal/dispatch"
+)
+
+// MiddlewareFunc is a function which receives an http.Handler and returns
+// another http.Handler. Middlewares run only when a route matches.
+type MiddlewareFunc func(http.Handler) http.Handler
+
+// Middleware allows MiddlewareFunc to implement the middleware interface
+func (mw MiddlewareFunc) Middleware(handler http.Handler) http.Handler {
+	return mw(handler)
+}
+
+// Router registers routes to be matched and dispatches a handler
+type Router struct {
+	// Configurable Handler to be used when no route matches
+	NotFoundHandler http.Handler
+
+	// Configurable Handler to be used when the request method does not match
+	// the route
+	MethodNotAllowedHandler http.Handler
+
+	routes      []*Route
+	middlewares []MiddlewareFunc
+	parent      *Route // route this subrouter was created from, nil for the root
+
+	once  sync.Once
+	table *dispatch.Table
+}
+
+// Route stores the conditions for matching a request and the handler to call
+type Route struct {
+	router  *Router
+	path    string
+	prefix  bool // path matches the whole subtree, set by PathPrefix
+	methods []string
+	host    string
+	handler http.Handler
+	sub     *Router
+	name    string
+	err     error
+}
+
+// NewRouter returns a new router instance
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// NewRoute registers an empty route. It panics once the router has started
+// serving.
+func (r *Router) NewRoute() *Route {
+	if r.root().table != nil {
+		panic("mux: cannot register a route after the router has started serving")
+	}
+	route := &Route{router: r}
+	r.routes = append(r.routes, route)
+	return route
+}
+
+// Handle registers a new route with a matcher for the URL path
+func (r *Router) Handle(path string, handler http.Handler) *Route {
+	return r.NewRoute().Path(path).Handler(handler)
+}
+
+// HandleFunc registers a new route with a matcher for the URL path
+func (r *Router) HandleFunc(path string, f func(http.ResponseWriter, *http.Request)) *Route {
+	return r.NewRoute().Path(path).HandlerFunc(f)
+}
+
+// Path registers a new route with a matcher for the URL path
+func (r *Router) Path(tpl string) *Route {
+	return r.NewRoute().Path(tpl)
+}
+
+// PathPrefix registers a new route with a matcher for the URL path prefix
+func (r *Router) PathPrefix(tpl string) *Route {
+	return r.NewRoute().PathPrefix(tpl)
+}
+
+// Methods registers a new route with a matcher for HTTP methods
+func (r *Router) Methods(methods ...string) *Route {
+	return r.NewRoute().Methods(methods...)
+}
+
+// Host registers a new route with a matcher for the URL host
+func (r *Router) Host(tpl string) *Route {
+	return r.NewRoute().Host(tpl)
+}
+
+// Use appends middlewares to the chain. Middlewares of a subrouter run after
+// those of its parent.
+func (r *Router) Use(mwf ...MiddlewareFunc) {
+	r.middlewares = append(r.middlewares, mwf...)
+}
+
+// Get returns a route registered with the given name, or nil
+func (r *Router) Get(name string) *Route {
+	for _, route := range r.routes {
+		if route.name == name {
+			return route
+		}
+		if route.sub != nil {
+			if found := route.sub.Get(name); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+// Path adds a matcher for the URL path. The template may contain variables
+// in the format {name} or {name:pattern}, each spanning a whole segment.
+func (r *Route) Path(tpl string) *Route {
+	r.path, r.prefix = tpl, false
+	r.checkPath()
+	return r
+}
+
+// PathPrefix adds a matcher for the URL path prefix. As in gorilla/mux, a
+// prefix ending in a slash matches only below it; otherwise it matches the
+// path itself and everything below it.
+func (r *Route) PathPrefix(tpl string) *Route {
+	r.path, r.prefix = tpl, true
+	r.checkPath()
+	return r
+}
+
+func (r *Route) checkPath() {
+	if r.err == nil {
+		if _, err := dispatch.Parse(r.fullPath()); err != nil {
+			r.err = err
+		}
+	}
+}
+
+// Methods adds a matcher for HTTP methods
+func (r *Route) Methods(methods ...string) *Route {
+	for _, method := range methods {
+		r.methods = append(r.methods, strings.ToUpper(method))
+	}
+	return r
+}
+
+// Host adds a matcher for the URL host. The template may contain variables
+// in the format {name} or {name:pattern}; a template without a port matches
+// any port.
+func (r *Route) Host(tpl string) *Route {
+	if _, _, err := compileHost(tpl); err != nil && r.err == nil {
+		r.err = err
+	}
+	r.host = tpl
+	return r
+}
+
+// Handler sets a handler for the route
+func (r *Route) Handler(handler http.Handler) *Route {
+	r.handler = handler
+	return r
+}
+
+// HandlerFunc sets a handler function for the route
+func (r *Route) HandlerFunc(f func(http.ResponseWriter, *http.Request)) *Route {
+	return r.Handler(http.HandlerFunc(f))
+}
+
+// Name sets the name for the route, used with Router.Get
+func (r *Route) Name(name string) *Route {
+	r.name = name
+	return r
+}
+
+// GetName returns the name for the route, if any
+func (r *Route) GetName() string {
+	return r.name
+}
+
+// GetError returns an error resulted from building the route, if any.
+// A route with an error never matches.
+func (r *Route) GetError() error {
+	return r.err
+}
+
+// Subrouter creates a subrouter for the route. Its routes are matched only
+// if this route's path prefix, host and methods match.
+func (r *Route) Subrouter() *Router {
+	r.sub = &Router{parent: r}
+	return r.sub
+}
+
+// fullPath is the path template of r including the paths of its parent routes
+func (r *Route) fullPath() string {
+	path := r.path
+	for parent := r.router.parent; parent != nil; parent = parent.router.parent {
+		path = parent.path + path
+	}
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+// Vars returns the route variables for the current request, if any
+func Vars(r *http.Request) map[string]string {
+	return fastrouter.PathParamsFromContext(r.Context())
+}
+
+// SetURLVars sets the URL variables for the given request, to be accessed via
+// mux.Vars for testing route behaviour
+func SetURLVars(r *http.Request, val map[string]string) *http.Request {
+	return fastrouter.WithPathParams(r, val)
+}
+
+// ServeHTTP dispatches the handler registered in the matched route. The
+// router is built on the first call.
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	root := r.root()
+	table := root.build()
+
+	if handler, matched := table.Lookup(req); handler != nil {
+		handler.ServeHTTP(w, matched)
+		return
+	}
+	if allowed := table.AllowedMethods(req); len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		if root.MethodNotAllowedHandler != nil {
+			root.MethodNotAllowedHandler.ServeHTTP(w, req)
+		} else {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	if root.NotFoundHandler != nil {
+		root.NotFoundHandler.ServeHTTP(w, req)
+	} else {
+		http.NotFound(w, req)
+	}
+}
+
+// root returns the router a subrouter was ultimately created from
+func (r *Router) root() *Router {
+	for r.parent != nil {
+		r = r.parent.router
+	}
+	return r
+}
+
+// build flattens the routes of r and its subrouters into a dispatch table, once
+func (r *Router) build() *dispatch.Table {
+	r.once.Do(func() {
+		var routes []*dispatch.Route
+		if err := r.flatten(conditions{}, &routes); err != nil {
+			panic("mux: " + err.Error())
+		}
+
+		table, err := dispatch.Build(routes)
+		if err != nil {
+			panic("mux: " + err.Error())
+		}
+		r.table = table
+	})
+	return r.table
+}
+
+// conditions are inherited by the routes of a subrouter
+type conditions struct {
+	path        string
+	prefix      bool
+	host        string
+	methods     []string
+	middlewares []MiddlewareFunc
+}
+
+// flatten appends the dispatch routes of r, in registration order
+func (r *Router) flatten(inherited conditions, routes *[]*dispatch.Route) error {
+	inherited.middlewares = append(append([]MiddlewareFunc(nil), inherited.middlewares...), r.middlewares...)
+
+	for _, route := range r.routes {
+		if route.err != nil {
+			continue
+		}
+
+		c := inherited
+		c.path += route.path
+		if route.path != "" {
+			c.prefix = route.prefix
+		}
+		if route.host != "" {
+			c.host = route.host
+		}
+		if len(route.methods) > 0 {
+			c.methods = route.methods
+		}
+
+		if route.sub != nil {
+			if err := route.sub.flatten(c, routes); err != nil {
+				return err
+			}
+			continue
+		}
+		if route.handler == nil {
+			continue
+		}
+
+		accept, err := hostAccept(c.host)
+		if err != nil {
+			return err
+		}
+
+		handler := route.handler
+		for i := len(c.middlewares) - 1; i >= 0; i-- {
+			handler = c.middlewares[i].Middleware(handler)
+		}
+
+		methods := c.methods
+		if len(methods) == 0 {
+			methods = dispatch.Methods
+		}
+
+		for _, path := range expandPath(c.path, c.prefix) {
+			pattern, err := dispatch.Parse(path)
+			if err != nil {
+				return err
+			}
+			for _, method := range methods {
+				*routes = append(*routes, &dispatch.Route{
+					Method:  method,
+					Pattern: pattern,
+					Handler: handler,
+					Accept:  accept,
+				})
+			}
+		}
+	}
+	return nil
+}
+
+// expandPath returns the fastrouter paths a template matches. A route without
+// a path, like a prefix route, matches the whole subtree.
+func expandPath(path string, prefix bool) []string {
+	switch {
+	case path == "" || path == "/" && prefix:
+		return []string{"/", "/*"}
+	case !prefix:
+		return []string{path}
+	case strings.HasSuffix(path, "/"):
+		return []string{path + "*"}
+	default:
+		return []string{path, path + "/*"}
+	}
+}
+
+// hostAccept returns the Accept function checking a host template. It also
+// drops the unnamed wildcard of prefix routes, which gorilla/mux does not
+// expose as a variable.
+func hostAccept(tpl string) (func(*http.Request, map[string]string) bool, error) {
+	if tpl == "" {
+		return func(req *http.Request, vars map[string]string) bool {
+			delete(vars, "*")
+			return true
+		}, nil
+	}
+
+	re, names, err := compileHost(tpl)
+	if err != nil {
+		return nil, err
+	}
+	withPort := strings.Contains(tpl, ":")
+
+	return func(req *http.Request, vars map[string]string) bool {
+		delete(vars, "*")
+
+		host := req.Host
+		if !withPort {
+			if h, _, err := net.SplitHostPort(host); err == nil {
+				host = h
+			}
+		}
+
+		m := re.FindStringSubmatch(host)
+		if m == nil {
+			return false
+		}
+		for i, name := range names {
+			vars[name] = m[re.SubexpIndex("v"+strconv.Itoa(i))]
+		}
+		return true
+	}, nil
+}
+
+// compileHost converts a host template into an anchored regexp with one
+// named group per variable
+func compileHost(tpl string) (*regexp.Regexp, []string, error) {
+	var pattern strings.Builder
+	var names []string
+
+	pattern.WriteString("^")
+	for i := 0; i < len(tpl); {
+		if tpl[i] != '{' {
+			end := strings.IndexByte(tpl[i:], '{')
+			if end < 0 {
+				end = len(tpl) - i
+			}
+			if strings.Contains(tpl[i:i+end], "}") {
+				return nil, nil, fmt.Errorf("host template %q: unbalanced braces", tpl)
+			}
+			pattern.WriteString(regexp.QuoteMeta(tpl[i : i+end]))
+			i += end
+			continue
+		}
+
+		depth, end := 0, -1
+		for j := i; j < len(tpl) && end < 0; j++ {
+			switch tpl[j] {
+			case '{':
+				depth++
+			case '}':
+				if depth--; depth == 0 {
+					end = j
+				}
+			}
+		}
+		if end < 0 {
+			return nil, nil, fmt.Errorf("host template %q: unbalanced braces", tpl)
+		}
+
+		name, expr, _ := strings.Cut(tpl[i+1:end], ":")
+		if name == "" {
+			return nil, nil, fmt.Errorf("host template %q: missing variable name", tpl)
+		}
+		if expr == "" {
+			expr = "[^.]+"
+		}
+		fmt.Fprintf(&pattern, "(?P<v%d>%s)", len(names), expr)
+		names = append(names, name)
+		i = end + 1
+	}
+	pattern.WriteString("$")
+
+	re, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return nil, nil, fmt.Errorf("host template %q: %v", tpl, err)
+	}
+	return re, names, nil
+}
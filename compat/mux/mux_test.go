@@ -0,0 +1,178 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// reply writes name followed by the sorted route variables
+func reply(name string) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := Vars(r)
+		keys := make([]string, 0, len(vars))
+		for key := range vars {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		body := name
+		for _, key := range keys {
+			body += " " + key + "=" + vars[key]
+		}
+		w.Write([]byte(body))
+	}
+}
+
+type conformanceCase struct {
+	method, host, path string
+	status             int
+	body               string
+}
+
+func runConformance(t *testing.T, router http.Handler, cases []conformanceCase) {
+	t.Helper()
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		if tc.host != "" {
+			req.Host = tc.host
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tc.status {
+			t.Errorf("%s %s%s: expected status %d, got %d", tc.method, tc.host, tc.path, tc.status, w.Code)
+			continue
+		}
+		if tc.body != "" && w.Body.String() != tc.body {
+			t.Errorf("%s %s%s: expected body '%s', got '%s'", tc.method, tc.host, tc.path, tc.body, w.Body.String())
+		}
+	}
+}
+
+// TestREADMEPaths replays the path examples from gorilla/mux's README
+func TestREADMEPaths(t *testing.T) {
+	r := NewRouter()
+	r.HandleFunc("/", reply("home"))
+	r.HandleFunc("/products", reply("products"))
+	r.HandleFunc("/articles", reply("articles"))
+	r.HandleFunc("/products/{key}", reply("product"))
+	r.HandleFunc("/articles/{category}/", reply("articlesCategory"))
+	r.HandleFunc("/articles/{category}/{id:[0-9]+}", reply("article"))
+
+	runConformance(t, r, []conformanceCase{
+		{"GET", "", "/", 200, "home"},
+		{"GET", "", "/products", 200, "products"},
+		{"POST", "", "/articles", 200, "articles"},
+		{"GET", "", "/products/apple", 200, "product key=apple"},
+		{"GET", "", "/articles/technology/", 200, "articlesCategory category=technology"},
+		{"GET", "", "/articles/technology/42", 200, "article category=technology id=42"},
+		{"GET", "", "/articles/technology/latest", 404, ""},
+		{"GET", "", "/articles/technology", 404, ""},
+	})
+}
+
+// TestConstraintFallthrough checks that a route rejected by its regexp lets
+// the next matching route serve the request, as gorilla/mux tries routes in turn
+func TestConstraintFallthrough(t *testing.T) {
+	r := NewRouter()
+	r.HandleFunc("/products/{key:[0-9]+}", reply("product"))
+	r.PathPrefix("/products/").HandlerFunc(reply("productsPrefix"))
+	r.HandleFunc("/items/{id:[0-9]+}", reply("item")).Host("www.example.com")
+	r.HandleFunc("/items/{name}", reply("itemByName"))
+
+	runConformance(t, r, []conformanceCase{
+		{"GET", "", "/products/42", 200, "product key=42"},
+		{"GET", "", "/products/abc", 200, "productsPrefix"},
+		{"GET", "", "/products/a/b", 200, "productsPrefix"},
+		{"GET", "www.example.com", "/items/7", 200, "item id=7"},
+		{"GET", "api.example.com", "/items/7", 200, "itemByName name=7"},
+	})
+}
+
+// TestREADMEMatching replays the host, method and subrouter examples from
+// gorilla/mux's README
+func TestREADMEMatching(t *testing.T) {
+	r := NewRouter()
+	r.HandleFunc("/products", reply("productsGet")).Host("www.example.com").Methods("GET")
+	r.HandleFunc("/products", reply("productsPost")).Methods("POST")
+
+	s := r.Host("{subdomain:[a-z]+}.example.com").Subrouter()
+	s.HandleFunc("/articles/{category}/{id:[0-9]+}", reply("article"))
+
+	p := r.PathPrefix("/shop").Subrouter()
+	p.HandleFunc("/", reply("shop"))
+	p.HandleFunc("/{key}/", reply("shopItem"))
+	p.HandleFunc("/{key}/details", reply("shopDetails"))
+
+	r.PathPrefix("/static/").HandlerFunc(reply("static"))
+
+	runConformance(t, r, []conformanceCase{
+		{"GET", "www.example.com", "/products", 200, "productsGet"},
+		{"GET", "www.example.com:8080", "/products", 200, "productsGet"},
+		{"GET", "api.example.com", "/products", 404, ""},
+		{"POST", "api.example.com", "/products", 200, "productsPost"},
+		{"DELETE", "www.example.com", "/products", 405, ""},
+		{"GET", "news.example.com", "/articles/tech/7", 200, "article category=tech id=7 subdomain=news"},
+		{"GET", "news42.example.com", "/articles/tech/7", 404, ""},
+		{"GET", "", "/shop/", 200, "shop"},
+		{"GET", "", "/shop/apple/", 200, "shopItem key=apple"},
+		{"GET", "", "/shop/apple/details", 200, "shopDetails key=apple"},
+		{"GET", "", "/static/css/site.css", 200, "static"},
+		{"GET", "", "/static", 404, ""},
+	})
+}
+
+func TestMiddlewareAndHandlers(t *testing.T) {
+	var calls []string
+	logging := func(name string) MiddlewareFunc {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	r := NewRouter()
+	r.Use(logging("root"))
+	api := r.PathPrefix("/api").Subrouter()
+	api.Use(logging("api"))
+	api.HandleFunc("/users/{id}", reply("user")).Methods("GET").Name("user")
+
+	if err := r.HandleFunc("/bad/{id", reply("bad")).GetError(); err == nil {
+		t.Error("Expected an error for an unbalanced path template")
+	}
+	if err := r.HandleFunc("/partial/{id}.json", reply("partial")).GetError(); err == nil {
+		t.Error("Expected an error for a variable that does not span a segment")
+	}
+
+	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	runConformance(t, r, []conformanceCase{
+		{"GET", "", "/api/users/42", 200, "user id=42"},
+		{"GET", "", "/missing", 418, ""},
+	})
+
+	if strings.Join(calls, ",") != "root,api" {
+		t.Errorf("Expected middlewares 'root,api' on a match only, got %v", calls)
+	}
+	if route := r.Get("user"); route == nil || route.GetName() != "user" {
+		t.Error("Expected to find the named route")
+	}
+
+	req := SetURLVars(httptest.NewRequest("GET", "/", nil), map[string]string{"id": "7"})
+	if Vars(req)["id"] != "7" {
+		t.Errorf("Expected SetURLVars to set id=7, got %v", Vars(req))
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected registration after serving to panic")
+		}
+	}()
+	r.HandleFunc("/late", reply("late"))
+}
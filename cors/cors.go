@@ -0,0 +1,311 @@
+// Package cors answers CORS preflight requests and sets the CORS headers of
+// actual requests in front of a fastrouter.Router. As it knows the router, the
+// methods it allows default to those registered for the requested path, and
+// routes or groups can carry their own policy.
+//
+//	policy := cors.New(cors.AllowOrigins("https://app.example.com", "https://*.example.com"),
+//		cors.AllowHeaders("Content-Type", "Authorization"))
+//	public := rb.Group("/public", cors.WithPolicy(cors.New(cors.AllowOrigins("*"))))
+//	...
+//	http.ListenAndServe(":8080", policy.Handler(router))
+package cors
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jamra/fastrouter"
+)
+
+// Policy is a set of CORS rules
+type Policy struct {
+	allowAll    bool
+	origins     map[string]bool
+	patterns    []originPattern
+	originFunc  func(origin string) bool
+	methods     []string
+	headers     []string
+	anyHeader   bool
+	expose      []string
+	credentials bool
+	maxAge      time.Duration
+}
+
+// originPattern matches origins with a "*" in place of one or more leading
+// subdomain labels, such as "https://*.example.com"
+type originPattern struct {
+	prefix string // scheme and "://"
+	suffix string // domain and port, starting with the dot after "*"
+}
+
+// parseOriginPattern accepts "*" only as the whole first label of the host,
+// so that the labels it matches always end at a dot of the domain
+func parseOriginPattern(origin string) (originPattern, bool) {
+	scheme, host, ok := strings.Cut(origin, "://")
+	if !ok || scheme == "" || !strings.HasPrefix(host, "*.") || strings.Count(host, "*") != 1 || len(host) < 3 {
+		return originPattern{}, false
+	}
+	return originPattern{prefix: scheme + "://", suffix: host[1:]}, true
+}
+
+func (p originPattern) match(origin string) bool {
+	if len(origin) <= len(p.prefix)+len(p.suffix) ||
+		!strings.HasPrefix(origin, p.prefix) || !strings.HasSuffix(origin, p.suffix) {
+		return false
+	}
+	return validLabels(origin[len(p.prefix) : len(origin)-len(p.suffix)])
+}
+
+// validLabels reports whether s is a sequence of dot-separated host labels
+func validLabels(s string) bool {
+	for _, label := range strings.Split(s, ".") {
+		if label == "" {
+			return false
+		}
+		for i := 0; i < len(label); i++ {
+			if c := label[i]; c != '-' && (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Option configures a Policy
+type Option func(*Policy)
+
+// AllowOrigins sets the origins allowed to make requests. "*" allows every
+// origin, and a "*" as the first label of the host, as in
+// "https://*.example.com", stands for any subdomain. A "*" anywhere else makes
+// New panic. Origins compare case-insensitively.
+func AllowOrigins(origins ...string) Option {
+	return func(p *Policy) {
+		for _, origin := range origins {
+			origin = strings.ToLower(origin)
+			switch {
+			case origin == "*":
+				p.allowAll = true
+			case strings.Contains(origin, "*"):
+				pattern, ok := parseOriginPattern(origin)
+				if !ok {
+					panic(`cors: origin pattern "` + origin + `" must have "*" as the whole first host label, as in "https://*.example.com"`)
+				}
+				p.patterns = append(p.patterns, pattern)
+			default:
+				p.origins[origin] = true
+			}
+		}
+	}
+}
+
+// AllowOriginFunc allows the origins for which allow returns true, in
+// addition to those set with AllowOrigins
+func AllowOriginFunc(allow func(origin string) bool) Option {
+	return func(p *Policy) {
+		p.originFunc = allow
+	}
+}
+
+// AllowMethods sets the methods preflight requests are told are allowed. By
+// default they are the methods registered on the router for the path.
+func AllowMethods(methods ...string) Option {
+	return func(p *Policy) {
+		p.methods = make([]string, len(methods))
+		for i, method := range methods {
+			p.methods[i] = strings.ToUpper(method)
+		}
+	}
+}
+
+// AllowHeaders sets the request headers preflight requests are told are
+// allowed. "*" allows whatever headers the preflight asks for.
+func AllowHeaders(headers ...string) Option {
+	return func(p *Policy) {
+		for _, header := range headers {
+			if header == "*" {
+				p.anyHeader = true
+				continue
+			}
+			p.headers = append(p.headers, http.CanonicalHeaderKey(header))
+		}
+	}
+}
+
+// ExposeHeaders sets the response headers scripts may read
+func ExposeHeaders(headers ...string) Option {
+	return func(p *Policy) {
+		for _, header := range headers {
+			p.expose = append(p.expose, http.CanonicalHeaderKey(header))
+		}
+	}
+}
+
+// AllowCredentials lets requests carry cookies and HTTP authentication. The
+// origin is then always echoed, since browsers reject "*" with credentials.
+func AllowCredentials() Option {
+	return func(p *Policy) {
+		p.credentials = true
+	}
+}
+
+// MaxAge sets how long browsers may cache a preflight response
+func MaxAge(d time.Duration) Option {
+	return func(p *Policy) {
+		p.maxAge = d
+	}
+}
+
+// New returns a Policy. Without AllowOrigins or AllowOriginFunc it allows no
+// origin.
+func New(opts ...Option) *Policy {
+	p := &Policy{origins: make(map[string]bool)}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// policyKey is the route value key of a route's policy
+type policyKey struct{}
+
+// WithPolicy makes a route, or every route of a group, use p instead of the
+// policy of the Handler in front of the router
+func WithPolicy(p *Policy) fastrouter.RouteOption {
+	return fastrouter.WithRouteValue(policyKey{}, p)
+}
+
+// Handler returns router behind p. Preflight requests are answered with 204
+// No Content for paths the router has routes for; other requests get their
+// CORS headers and are served by the router. A preflight is served by the
+// router too when the route for the requested method has an OPTIONS route on
+// the same pattern, so that routes registered for OPTIONS answer their own
+// preflights. An OPTIONS route on another pattern, such as a method-less
+// catch-all, does not take preflights away from the routes below it.
+func (p *Policy) Handler(router *fastrouter.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			router.ServeHTTP(w, r)
+			return
+		}
+
+		requested := r.Header.Get("Access-Control-Request-Method")
+		if r.Method == http.MethodOptions && requested != "" {
+			if p.preflight(w, r, router, origin, requested) {
+				return
+			}
+		} else {
+			p.policyFor(router, r.Method, r.URL.Path).actual(w, origin)
+		}
+		router.ServeHTTP(w, r)
+	})
+}
+
+// policyFor returns the policy of the route for method and path, or p
+func (p *Policy) policyFor(router *fastrouter.Router, method, path string) *Policy {
+	handler, params, route := router.MatchRoute(method, path)
+	fastrouter.ReleaseParams(params)
+	if handler != nil {
+		if policy, ok := route.Value(policyKey{}).(*Policy); ok {
+			return policy
+		}
+	}
+	return p
+}
+
+// preflight answers a preflight request, and reports false when the router
+// should answer it instead: when it has no route for the path, or the route
+// for the requested method answers OPTIONS itself
+func (p *Policy) preflight(w http.ResponseWriter, r *http.Request, router *fastrouter.Router, origin, requested string) bool {
+	policy := p
+	handler, params, route := router.MatchRoute(requested, r.URL.Path)
+	fastrouter.ReleaseParams(params)
+	if handler != nil {
+		_, params, options := router.MatchRoute(http.MethodOptions, r.URL.Path)
+		fastrouter.ReleaseParams(params)
+		if options != nil && options.Pattern == route.Pattern {
+			return false
+		}
+		if own, ok := route.Value(policyKey{}).(*Policy); ok {
+			policy = own
+		}
+	}
+
+	// The registered methods are only looked up when needed
+	methods := policy.methods
+	if handler == nil || methods == nil {
+		registered := router.AllowedMethods(r.URL.Path)
+		if len(registered) == 0 {
+			return false
+		}
+		if methods == nil {
+			methods = registered
+		}
+	}
+
+	header := w.Header()
+	header.Add("Vary", "Origin")
+	header.Add("Vary", "Access-Control-Request-Method")
+	header.Add("Vary", "Access-Control-Request-Headers")
+
+	if policy.allowOrigin(header, origin) {
+		header.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+		if policy.anyHeader {
+			if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+				header.Set("Access-Control-Allow-Headers", headers)
+			}
+		} else if len(policy.headers) > 0 {
+			header.Set("Access-Control-Allow-Headers", strings.Join(policy.headers, ", "))
+		}
+		if policy.maxAge > 0 {
+			header.Set("Access-Control-Max-Age", strconv.Itoa(int(policy.maxAge/time.Second)))
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+// actual sets the CORS headers of an actual request from origin
+func (p *Policy) actual(w http.ResponseWriter, origin string) {
+	header := w.Header()
+	header.Add("Vary", "Origin")
+	if p.allowOrigin(header, origin) && len(p.expose) > 0 {
+		header.Set("Access-Control-Expose-Headers", strings.Join(p.expose, ", "))
+	}
+}
+
+// allowOrigin sets the origin and credentials headers if origin is allowed,
+// and reports whether it is
+func (p *Policy) allowOrigin(header http.Header, origin string) bool {
+	if !p.allows(origin) {
+		return false
+	}
+	if p.allowAll && !p.credentials {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+	if p.credentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+	return true
+}
+
+// allows reports whether origin is allowed
+func (p *Policy) allows(origin string) bool {
+	if p.allowAll {
+		return true
+	}
+	lower := strings.ToLower(origin)
+	if p.origins[lower] {
+		return true
+	}
+	for _, pattern := range p.patterns {
+		if pattern.match(lower) {
+			return true
+		}
+	}
+	return p.originFunc != nil && p.originFunc(origin)
+}
@@ -0,0 +1,234 @@
+package cors_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamra/fastrouter"
+	"github.com/jamra/fastrouter/cors"
+)
+
+func newTestHandler(t *testing.T) http.Handler {
+	t.Helper()
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Total", "1")
+		w.Write([]byte(r.Method))
+	})
+
+	rb := fastrouter.NewRouterBuilder()
+	public := rb.Group("/public", cors.WithPolicy(cors.New(cors.AllowOrigins("*"))))
+	public.AddRoute("GET", "/feed", ok)
+	rb.AddRoute("DELETE", "/users/:id", ok)
+	rb.AddRoute("GET", "/users/:id", ok)
+	rb.AddRoute("PUT", "/users/:id", ok)
+	router, err := rb.Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	policy := cors.New(
+		cors.AllowOrigins("https://app.example.com", "https://*.staging.example.com"),
+		cors.AllowHeaders("content-type", "Authorization"),
+		cors.ExposeHeaders("x-total"),
+		cors.AllowCredentials(),
+		cors.MaxAge(10*time.Minute),
+	)
+	return policy.Handler(router)
+}
+
+func preflight(handler http.Handler, path, origin, method string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("OPTIONS", path, nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	req.Header.Set("Access-Control-Request-Headers", "content-type, x-custom")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestPreflight(t *testing.T) {
+	handler := newTestHandler(t)
+
+	w := preflight(handler, "/users/42", "https://app.example.com", "PUT")
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", w.Code)
+	}
+	for header, want := range map[string]string{
+		"Access-Control-Allow-Origin":      "https://app.example.com",
+		"Access-Control-Allow-Methods":     "DELETE, GET, PUT",
+		"Access-Control-Allow-Headers":     "Content-Type, Authorization",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Max-Age":           "600",
+	} {
+		if got := w.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+	if vary := strings.Join(w.Header().Values("Vary"), ", "); vary != "Origin, Access-Control-Request-Method, Access-Control-Request-Headers" {
+		t.Errorf("Vary = %q", vary)
+	}
+
+	// Origin patterns
+	w = preflight(handler, "/users/42", "https://pr-7.staging.example.com", "GET")
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://pr-7.staging.example.com" {
+		t.Errorf("Expected the pattern to allow the origin, got %q", got)
+	}
+
+	// Disallowed origins get no CORS headers
+	w = preflight(handler, "/users/42", "https://evil.example.org", "GET")
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != "" || w.Header().Get("Access-Control-Allow-Methods") != "" {
+		t.Errorf("Expected no CORS headers for a disallowed origin, got %v", w.Header())
+	}
+
+	// Unknown paths are left to the router
+	if w := preflight(handler, "/missing", "https://app.example.com", "GET"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown path, got %d", w.Code)
+	}
+}
+
+func TestActualRequest(t *testing.T) {
+	handler := newTestHandler(t)
+
+	req := httptest.NewRequest("GET", "/users/42", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Body.String() != "GET" {
+		t.Errorf("Expected the route to be served, got %q", w.Body.String())
+	}
+	if w.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" ||
+		w.Header().Get("Access-Control-Expose-Headers") != "X-Total" ||
+		w.Header().Get("Vary") != "Origin" {
+		t.Errorf("Unexpected CORS headers %v", w.Header())
+	}
+
+	// Same-origin requests are untouched
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/users/42", nil))
+	if w.Header().Get("Vary") != "" || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("Expected no CORS headers without an Origin, got %v", w.Header())
+	}
+}
+
+func TestRoutePolicy(t *testing.T) {
+	handler := newTestHandler(t)
+
+	w := preflight(handler, "/public/feed", "https://anyone.test", "GET")
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Expected the group policy to allow any origin, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("Expected no credentials from the group policy, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Methods"); got != "GET" {
+		t.Errorf("Expected the registered methods, got %q", got)
+	}
+
+	req := httptest.NewRequest("GET", "/public/feed", nil)
+	req.Header.Set("Origin", "https://anyone.test")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Expected the group policy on actual requests, got %q", got)
+	}
+}
+
+func TestAllowHeadersAny(t *testing.T) {
+	rb := fastrouter.NewRouterBuilder()
+	rb.AddRoute("POST", "/items", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	router, _ := rb.Build()
+	policy := cors.New(cors.AllowOriginFunc(func(origin string) bool { return strings.HasSuffix(origin, ".test") }),
+		cors.AllowHeaders("*"), cors.AllowMethods("post", "put"))
+
+	w := preflight(policy.Handler(router), "/items", "https://a.test", "POST")
+	if got := w.Header().Get("Access-Control-Allow-Headers"); got != "content-type, x-custom" {
+		t.Errorf("Expected the requested headers to be echoed, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Methods"); got != "POST, PUT" {
+		t.Errorf("Expected the configured methods, got %q", got)
+	}
+}
+
+func TestOriginPatterns(t *testing.T) {
+	rb := fastrouter.NewRouterBuilder()
+	rb.AddRoute("GET", "/items", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	router, _ := rb.Build()
+	handler := cors.New(cors.AllowOrigins("https://*.example.com", "http://*.local.test:8080")).Handler(router)
+
+	for origin, allowed := range map[string]bool{
+		"https://app.example.com":         true,
+		"https://a.b.example.com":         true,
+		"https://APP.example.com":         true,
+		"http://dev.local.test:8080":      true,
+		"https://example.com":             false,
+		"https://.example.com":            false,
+		"https://attackerexample.com":     false,
+		"https://app.example.com.evil":    false,
+		"https://app.example.com:8443":    false,
+		"http://app.example.com":          false,
+		"https://a/b.example.com":         false,
+		"https://user@app.example.com":    false,
+		"http://dev.local.test":           false,
+		"http://dev.local.test:8080.evil": false,
+	} {
+		req := httptest.NewRequest("GET", "/items", nil)
+		req.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if got := w.Header().Get("Access-Control-Allow-Origin") != ""; got != allowed {
+			t.Errorf("%s: expected allowed %v, got %v", origin, allowed, got)
+		}
+	}
+}
+
+func TestInvalidOriginPatterns(t *testing.T) {
+	for _, origin := range []string{
+		"https://example.*",
+		"https://*example.com",
+		"https://app.*.example.com",
+		"https://*.*.example.com",
+		"*.example.com",
+		"https://*",
+		"https://*.",
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected New to reject %q", origin)
+				}
+			}()
+			cors.New(cors.AllowOrigins(origin))
+		}()
+	}
+}
+
+func TestRegisteredOptionsRoute(t *testing.T) {
+	text := func(body string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(body)) })
+	}
+	rb := fastrouter.NewRouterBuilder()
+	rb.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("catch-all")) })
+	rb.AddRoute("GET", "/items", text("items"))
+	rb.AddRoute("OPTIONS", "/users/:id", text("custom"))
+	rb.AddRoute("PUT", "/users/:id", text("put"))
+	router, err := rb.Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	handler := cors.New(cors.AllowOrigins("https://app.example.com")).Handler(router)
+
+	// The route's own OPTIONS handler answers its preflights
+	w := preflight(handler, "/users/42", "https://app.example.com", "PUT")
+	if w.Code != http.StatusOK || w.Body.String() != "custom" || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("Expected the OPTIONS route to answer, got %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+
+	// A catch-all matching OPTIONS does not shadow the routes below it
+	w = preflight(handler, "/items", "https://app.example.com", "GET")
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+		t.Errorf("Expected the policy to answer, got %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+}
@@ -26,8 +26,8 @@ func main() {
 	// Routes in lexicographic order (FST requirement)
 	builder.AddRoute("GET", "/", http.HandlerFunc(homeHandler))
 	builder.AddRoute("GET", "/api/users", http.HandlerFunc(listUsersHandler))
-	builder.AddRoute("GET", "/api/users/:id", http.HandlerFunc(getUserHandler))
 	builder.AddRoute("POST", "/api/users", http.HandlerFunc(createUserHandler))
+	builder.AddRouteFunc("GET", "/api/users/:id", getUserHandler)
 	
 	router, err := builder.Build()
 	if err != nil {
@@ -69,8 +69,7 @@ func listUsersHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func getUserHandler(w http.ResponseWriter, r *http.Request) {
-	params := fastrouter.GetPathParams(r)
+func getUserHandler(w http.ResponseWriter, r *http.Request, params fastrouter.PathParams) {
 	idStr := params["id"]
 	
 	id, err := strconv.Atoi(idStr)
@@ -0,0 +1,195 @@
+package fastrouter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ServeFiles serves the files of fsys for GET and HEAD under pattern, which
+// must end in a wildcard such as "/static/*" or "/static/{path...}". The
+// wildcard value is the file path within fsys; paths with ".." segments are
+// rejected. Directories serve their index.html. Responses carry an ETag and
+// honour If-None-Match and Range, and a sibling "name.br" or "name.gz" is
+// served instead of "name" when the client accepts that encoding. fsys is
+// typically an embed.FS, narrowed with fs.Sub, or os.DirFS.
+func (rb *RouterBuilder) ServeFiles(pattern string, fsys fs.FS) error {
+	path, err := ConvertPattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	wild := path[strings.LastIndexByte(path, '/')+1:]
+	if !strings.HasPrefix(wild, "*") {
+		return fmt.Errorf("serve files %q: pattern must end in a wildcard", pattern)
+	}
+	param := wild[1:]
+	if param == "" {
+		param = "*"
+	}
+
+	files := &fileServer{fsys: fsys, param: param}
+	if err := rb.AddRoute(http.MethodGet, path, files); err != nil {
+		return err
+	}
+	return rb.AddRoute(http.MethodHead, path, files)
+}
+
+// fileServer serves the files of an fs.FS named by a wildcard param
+type fileServer struct {
+	fsys  fs.FS
+	param string
+	etags sync.Map // file name -> content hash ETag, for files without a modification time
+}
+
+// encodings are the precompressed siblings tried in order of preference
+var encodings = []struct{ name, ext string }{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// ServeHTTPParams serves the file named by the wildcard param
+func (s *fileServer) ServeHTTPParams(w http.ResponseWriter, r *http.Request, params PathParams) {
+	name := params[s.param]
+	if containsDotDot(name) || strings.ContainsAny(name, "\\\x00") {
+		http.Error(w, "invalid URL path", http.StatusBadRequest)
+		return
+	}
+
+	name = strings.Trim(path.Clean("/"+name), "/")
+	if name == "" {
+		name = "."
+	}
+
+	info, err := fs.Stat(s.fsys, name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if info.IsDir() {
+		// Relative links in the index only resolve below a trailing slash
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			http.Redirect(w, r, path.Base(r.URL.Path)+"/", http.StatusMovedPermanently)
+			return
+		}
+		name = path.Join(name, "index.html")
+		if info, err = fs.Stat(s.fsys, name); err != nil || info.IsDir() {
+			http.NotFound(w, r)
+			return
+		}
+	}
+
+	// Serve a precompressed sibling the client accepts
+	served, encoding, varies := name, "", false
+	for _, enc := range encodings {
+		sibling, err := fs.Stat(s.fsys, name+enc.ext)
+		if err != nil || sibling.IsDir() {
+			continue
+		}
+		varies = true
+		if encoding == "" && acceptsEncoding(r, enc.name) {
+			served, encoding, info = name+enc.ext, enc.name, sibling
+		}
+	}
+	if varies {
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+
+	f, err := s.fsys.Open(served)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		content = bytes.NewReader(data)
+	}
+
+	etag, err := s.etag(served, info, content)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	if encoding != "" {
+		w.Header().Set("Content-Encoding", encoding)
+	}
+
+	// ServeContent handles If-None-Match, If-Modified-Since, Range and HEAD,
+	// and picks the Content-Type from the uncompressed name
+	http.ServeContent(w, r, name, info.ModTime(), content)
+}
+
+// ServeHTTP serves the file named by the wildcard param found in the request
+// context, for a file server registered behind a wrapper or on another router
+func (s *fileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.ServeHTTPParams(w, r, GetPathParams(r))
+}
+
+// etag derives a strong ETag from the modification time and size, or, for
+// files without a modification time such as those of an embed.FS, from a
+// hash of the content that is computed once per file
+func (s *fileServer) etag(name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	if !info.ModTime().IsZero() {
+		return `"` + strconv.FormatInt(info.ModTime().UnixNano(), 36) + "-" + strconv.FormatInt(info.Size(), 36) + `"`, nil
+	}
+	if etag, ok := s.etags.Load(name); ok {
+		return etag.(string), nil
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	etag := `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+	s.etags.Store(name, etag)
+	return etag, nil
+}
+
+// containsDotDot reports whether any slash-separated element of name is ".."
+func containsDotDot(name string) bool {
+	for _, element := range strings.Split(name, "/") {
+		if element == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsEncoding reports whether the Accept-Encoding header of r lists
+// encoding with a non-zero quality
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	for _, header := range r.Header.Values("Accept-Encoding") {
+		for _, part := range strings.Split(header, ",") {
+			coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+			if !strings.EqualFold(strings.TrimSpace(coding), encoding) {
+				continue
+			}
+			if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+				if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package fastrouter
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+//go:embed testdata/static
+var testStatic embed.FS
+
+func TestServeFilesEmbed(t *testing.T) {
+	static, err := fs.Sub(testStatic, "testdata/static")
+	if err != nil {
+		t.Fatalf("Error opening embedded files: %v", err)
+	}
+
+	rb := NewRouterBuilder()
+	if err := rb.ServeFiles("/static/*", static); err != nil {
+		t.Fatalf("Error adding file server: %v", err)
+	}
+	router, err := rb.Build()
+	if err != nil {
+		t.Fatalf("Error building router: %v", err)
+	}
+
+	for _, tc := range []struct {
+		method, path string
+		status       int
+		body         string
+		header       string // expected Content-Type or Location
+	}{
+		{"GET", "/static/css/site.css", 200, "body { margin: 0; }\n", "text/css; charset=utf-8"},
+		{"HEAD", "/static/css/site.css", 200, "", "text/css; charset=utf-8"},
+		{"GET", "/static/", 200, "<!doctype html><title>index</title>\n", "text/html; charset=utf-8"},
+		{"GET", "/static/docs/", 200, "docs\n", "text/html; charset=utf-8"},
+		{"GET", "/static/docs", 301, "", "/static/docs/"},
+		{"GET", "/static/missing.css", 404, "", ""},
+		{"GET", "/static/../files.go", 400, "", ""},
+		{"GET", "/static/css/%2e%2e/%2e%2e/files.go", 400, "", ""},
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		if w.Code != tc.status {
+			t.Errorf("%s %s: expected status %d, got %d", tc.method, tc.path, tc.status, w.Code)
+			continue
+		}
+		if tc.status == 200 && w.Body.String() != tc.body {
+			t.Errorf("%s %s: expected body %q, got %q", tc.method, tc.path, tc.body, w.Body.String())
+		}
+		header := w.Header().Get("Content-Type")
+		if tc.status == 301 {
+			header = w.Header().Get("Location")
+		}
+		if tc.header != "" && header != tc.header {
+			t.Errorf("%s %s: expected header '%s', got '%s'", tc.method, tc.path, tc.header, header)
+		}
+	}
+}
+
+func TestServeFilesConditionalAndRange(t *testing.T) {
+	rb := NewRouterBuilder()
+	rb.ServeFiles("/files/{path...}", fstest.MapFS{
+		"hello.txt": {Data: []byte("hello, world")},
+	})
+	router, _ := rb.Build()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/files/hello.txt", nil))
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" {
+		t.Fatalf("Expected 200 with an ETag, got %d with '%s'", w.Code, etag)
+	}
+
+	req := httptest.NewRequest("GET", "/files/hello.txt", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("Expected 304 for a matching If-None-Match, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/files/hello.txt", nil)
+	req.Header.Set("Range", "bytes=7-11")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusPartialContent || w.Body.String() != "world" {
+		t.Errorf("Expected 206 'world', got %d '%s'", w.Code, w.Body.String())
+	}
+}
+
+func TestServeFilesPrecompressed(t *testing.T) {
+	rb := NewRouterBuilder()
+	rb.ServeFiles("/assets/*", fstest.MapFS{
+		"app.js":    {Data: []byte("plain")},
+		"app.js.br": {Data: []byte("brotli")},
+		"app.js.gz": {Data: []byte("gzipped")},
+	})
+	router, _ := rb.Build()
+
+	etags := make(map[string]bool)
+	for _, tc := range []struct{ accept, encoding, body string }{
+		{"", "", "plain"},
+		{"gzip, deflate", "gzip", "gzipped"},
+		{"gzip, br", "br", "brotli"},
+		{"br;q=0, gzip;q=0.5", "gzip", "gzipped"},
+		{"identity", "", "plain"},
+	} {
+		req := httptest.NewRequest("GET", "/assets/app.js", nil)
+		if tc.accept != "" {
+			req.Header.Set("Accept-Encoding", tc.accept)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Body.String() != tc.body || w.Header().Get("Content-Encoding") != tc.encoding {
+			t.Errorf("Accept-Encoding '%s': expected '%s' encoded '%s', got '%s' encoded '%s'",
+				tc.accept, tc.body, tc.encoding, w.Body.String(), w.Header().Get("Content-Encoding"))
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "text/javascript; charset=utf-8" {
+			t.Errorf("Accept-Encoding '%s': expected the JavaScript content type, got '%s'", tc.accept, ct)
+		}
+		if w.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("Accept-Encoding '%s': expected Vary: Accept-Encoding", tc.accept)
+		}
+		etags[tc.encoding+" "+w.Header().Get("ETag")] = true
+	}
+
+	// Each representation has its own ETag
+	if len(etags) != 3 {
+		t.Errorf("Expected 3 distinct encoding/ETag pairs, got %v", etags)
+	}
+}
+
+func TestServeFilesPattern(t *testing.T) {
+	if err := NewRouterBuilder().ServeFiles("/static", fstest.MapFS{}); err == nil {
+		t.Error("Expected an error for a pattern without a wildcard")
+	}
+}
@@ -0,0 +1,60 @@
+package fastrouter
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Group adds routes to a RouterBuilder under a shared path prefix and shared
+// route options, such as Timeout. Options given when adding a route apply
+// after the group's, so they take precedence.
+type Group struct {
+	rb     *RouterBuilder
+	prefix string
+	opts   []RouteOption
+}
+
+// Group returns a group of routes under prefix, which may contain parameters.
+// The group's routes follow the builder's ordering rule once prefixed.
+func (rb *RouterBuilder) Group(prefix string, opts ...RouteOption) *Group {
+	return &Group{rb: rb, prefix: strings.TrimSuffix(prefix, "/"), opts: opts}
+}
+
+// Group returns a nested group under the group's prefix, inheriting its options
+func (g *Group) Group(prefix string, opts ...RouteOption) *Group {
+	return &Group{rb: g.rb, prefix: g.path(prefix), opts: g.with(opts)}
+}
+
+// AddRoute adds a route under the group's prefix. The path "/" registers the
+// prefix itself.
+func (g *Group) AddRoute(method, path string, handler http.Handler, opts ...RouteOption) error {
+	return g.rb.AddRoute(method, g.path(path), handler, g.with(opts)...)
+}
+
+// AddRouteFunc adds a params-aware route under the group's prefix
+func (g *Group) AddRouteFunc(method, path string, fn HandlerFunc, opts ...RouteOption) error {
+	return g.AddRoute(method, path, fn, opts...)
+}
+
+// path joins path onto the group's prefix
+func (g *Group) path(path string) string {
+	if path == "" || path == "/" {
+		if g.prefix == "" {
+			return "/"
+		}
+		return g.prefix
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return g.prefix + path
+}
+
+// with returns the group's options followed by opts
+func (g *Group) with(opts []RouteOption) []RouteOption {
+	if len(opts) == 0 {
+		return g.opts
+	}
+	all := make([]RouteOption, 0, len(g.opts)+len(opts))
+	return append(append(all, g.opts...), opts...)
+}
@@ -0,0 +1,64 @@
+package fastrouter
+
+import (
+	"context"
+	"net/http"
+)
+
+// HandlerFunc is a params-aware handler. It implements both ParamsHandler, so
+// Router.ServeHTTP passes params directly, and http.Handler, so it can be
+// registered with AddRoute like any other handler. Read params from the
+// argument: r.PathValue is only set for plain http.Handlers.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request, params PathParams)
+
+// ServeHTTPParams calls f(w, r, params)
+func (f HandlerFunc) ServeHTTPParams(w http.ResponseWriter, r *http.Request, params PathParams) {
+	f(w, r, params)
+}
+
+// ServeHTTP calls f with the params stored in the request context, for use
+// behind middleware or outside the router
+func (f HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f(w, r, GetPathParams(r))
+}
+
+// AddRouteFunc adds a params-aware route to the builder
+func (rb *RouterBuilder) AddRouteFunc(method, path string, fn HandlerFunc, opts ...RouteOption) error {
+	return rb.AddRoute(method, path, fn, opts...)
+}
+
+// FromHandler adapts a plain http.Handler to a HandlerFunc. A copy of the
+// params is stored in the request context so the handler can read them with
+// GetPathParams, also after it returns.
+func FromHandler(h http.Handler) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, params PathParams) {
+		h.ServeHTTP(w, WithPathParams(r, copyParams(params)))
+	}
+}
+
+// ToHandler adapts a ParamsHandler to a plain http.Handler that reads its
+// params from the request context
+func ToHandler(h ParamsHandler) http.Handler {
+	if f, ok := h.(HandlerFunc); ok {
+		return f
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTPParams(w, r, GetPathParams(r))
+	})
+}
+
+// WithPathParams returns a shallow copy of r whose context carries params,
+// or r itself when there are none. Each param is also set with SetPathValue so
+// standard library style handlers can read it with r.PathValue. params must
+// live as long as r: copy a pooled map from Match before passing it in.
+func WithPathParams(r *http.Request, params PathParams) *http.Request {
+	if len(params) == 0 {
+		return r
+	}
+
+	r = r.WithContext(context.WithValue(r.Context(), pathParamsKey, params))
+	for name, value := range params {
+		r.SetPathValue(name, value)
+	}
+	return r
+}
@@ -0,0 +1,91 @@
+package fastrouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandlerFunc(t *testing.T) {
+	rb := NewRouterBuilder()
+
+	err := rb.AddRouteFunc("GET", "/users/:id", func(w http.ResponseWriter, r *http.Request, params PathParams) {
+		w.Write([]byte("user:" + params["id"]))
+	})
+	if err != nil {
+		t.Fatalf("Error adding route: %v", err)
+	}
+
+	// A plain http.Handler adapted to receive params through the context
+	err = rb.AddRoute("GET", "/users/:id/posts/:postId", FromHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := GetPathParams(r)
+		w.Write([]byte("post:" + params["id"] + "/" + params["postId"]))
+	})))
+	if err != nil {
+		t.Fatalf("Error adding route: %v", err)
+	}
+
+	router, err := rb.Build()
+	if err != nil {
+		t.Fatalf("Error building router: %v", err)
+	}
+
+	for _, tc := range []struct{ path, expected string }{
+		{"/users/42", "user:42"},
+		{"/users/42/posts/7", "post:42/7"},
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", tc.path, nil))
+		if w.Body.String() != tc.expected {
+			t.Errorf("Expected response '%s' for %s, got '%s'", tc.expected, tc.path, w.Body.String())
+		}
+	}
+}
+
+func TestHandlerAdapters(t *testing.T) {
+	params := PathParams{"id": "42"}
+	req := WithPathParams(httptest.NewRequest("GET", "/users/42", nil), params)
+
+	// HandlerFunc called as a plain http.Handler reads params from the context
+	fn := HandlerFunc(func(w http.ResponseWriter, r *http.Request, p PathParams) {
+		w.Write([]byte("id:" + p["id"]))
+	})
+	w := httptest.NewRecorder()
+	fn.ServeHTTP(w, req)
+	if w.Body.String() != "id:42" {
+		t.Errorf("Expected response 'id:42', got '%s'", w.Body.String())
+	}
+
+	// Any ParamsHandler can be turned back into a plain http.Handler
+	w = httptest.NewRecorder()
+	ToHandler(paramsEcho{}).ServeHTTP(w, req)
+	if w.Body.String() != "direct:42" {
+		t.Errorf("Expected response 'direct:42', got '%s'", w.Body.String())
+	}
+
+	// WithPathParams leaves requests without params untouched
+	plain := httptest.NewRequest("GET", "/", nil)
+	if WithPathParams(plain, nil) != plain {
+		t.Error("Expected WithPathParams to return the same request for empty params")
+	}
+}
+
+func TestPathParamsOutliveHandler(t *testing.T) {
+	var kept []*http.Request
+	keep := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		kept = append(kept, r)
+	})
+	rb := NewRouterBuilder()
+	rb.AddRoute("GET", "/adapted/:id", FromHandler(keep))
+	rb.AddRoute("GET", "/users/:id", keep)
+	router, _ := rb.Build()
+
+	for _, path := range []string{"/users/1", "/adapted/2", "/users/3", "/adapted/4"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	for i, want := range []string{"1", "2", "3", "4"} {
+		if got := GetPathParams(kept[i])["id"]; got != want {
+			t.Errorf("request %d: expected id %q after the handler returned, got %q", i, want, got)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Package respwriter wraps an http.ResponseWriter to track the status and
+// size of the response, for the router and its middleware packages.
+package respwriter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+)
+
+// Writer records the status and body size of a response. It keeps the
+// Flusher, Hijacker and ReaderFrom behaviour of the wrapped writer, and
+// exposes it through Unwrap for http.ResponseController.
+type Writer struct {
+	http.ResponseWriter
+	Status int   // 0 until the header is written
+	Size   int64 // body bytes written
+}
+
+// Wrap returns a Writer for w
+func Wrap(w http.ResponseWriter) *Writer {
+	return &Writer{ResponseWriter: w}
+}
+
+// Reset points the Writer at w and clears what it recorded, for pooling
+func (w *Writer) Reset(rw http.ResponseWriter) {
+	*w = Writer{ResponseWriter: rw}
+}
+
+// WriteHeader sends the status code once. Informational 1xx codes other than
+// 101 Switching Protocols may precede it; later calls are dropped rather than
+// logged as superfluous by net/http.
+func (w *Writer) WriteHeader(code int) {
+	if w.Status != 0 {
+		return
+	}
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.Status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes the body, sending a 200 status first if none was written
+func (w *Writer) Write(b []byte) (int, error) {
+	if w.Status == 0 {
+		w.Status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.Size += int64(n)
+	return n, err
+}
+
+// ReadFrom keeps io.Copy on the underlying writer's fast path, such as
+// sendfile for an *os.File
+func (w *Writer) ReadFrom(src io.Reader) (int64, error) {
+	if w.Status == 0 {
+		w.Status = http.StatusOK
+	}
+	var n int64
+	var err error
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(src)
+	} else {
+		n, err = io.Copy(writerOnly{w.ResponseWriter}, src)
+	}
+	w.Size += n
+	return n, err
+}
+
+// writerOnly hides every method but Write, so io.Copy does not loop back
+// into ReadFrom
+type writerOnly struct {
+	io.Writer
+}
+
+// Flush sends any buffered data, and the header if it was not sent yet
+func (w *Writer) Flush() {
+	if w.Status == 0 {
+		w.Status = http.StatusOK
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets the handler take over the connection, if the underlying
+// ResponseWriter supports it
+func (w *Writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T does not implement http.Hijacker", w.ResponseWriter)
+	}
+	if w.Status == 0 {
+		w.Status = http.StatusSwitchingProtocols
+	}
+	return hj.Hijack()
+}
+
+// Unwrap returns the underlying ResponseWriter, for http.ResponseController
+func (w *Writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Written reports whether the response header has been sent
+func (w *Writer) Written() bool {
+	return w.Status != 0
+}
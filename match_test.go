@@ -0,0 +1,164 @@
+package fastrouter
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// referenceMatch is the original segment-splitting Match algorithm, kept as the
+// behavioral reference for the allocation-free implementation
+func referenceMatch(r *Router, method, path string) (http.Handler, PathParams) {
+	if path == "" || path[0] != '/' {
+		path = "/" + path
+	}
+
+	segments := strings.Split(path, "/")[1:] // Skip empty first element
+	if len(segments) == 1 && segments[0] == "" {
+		segments = []string{} // Handle root path "/"
+	}
+
+	params := make(PathParams)
+	mi := methodIndex(method)
+	root := r.tree(mi, method)
+	if root == nil {
+		return nil, params
+	}
+	return referenceMatchNode(root, segments, mi, method, params), params
+}
+
+func referenceMatchNode(n *node, segments []string, mi int, method string, params PathParams) http.Handler {
+	if len(segments) == 0 {
+		return n.handler(mi, method)
+	}
+
+	segment := segments[0]
+	remaining := segments[1:]
+
+	if child, exists := n.children[segment]; exists {
+		if handler := referenceMatchNode(child, remaining, mi, method, params); handler != nil {
+			return handler
+		}
+	}
+
+	for _, child := range n.paramChild {
+		params[child.paramName] = segment
+		if handler := referenceMatchNode(child, remaining, mi, method, params); handler != nil {
+			return handler
+		}
+		delete(params, child.paramName) // backtrack
+	}
+
+	if n.wildChild != nil {
+		if handler := n.wildChild.handler(mi, method); handler != nil {
+			params[n.wildChild.paramName] = strings.Join(segments, "/")
+			return handler
+		}
+	}
+
+	return nil
+}
+
+func TestMatchAgreesWithReference(t *testing.T) {
+	routeSets := map[string][]string{
+		"static": {
+			"/",
+			"/api",
+			"/api/posts",
+			"/api/users",
+			"/healthz",
+		},
+		"dynamic": {
+			"/",
+			"/api/users",
+			"/api/users/:id",
+			"/api/users/:id/posts",
+			"/api/users/:id/posts/:postId",
+			"/api/users/me",
+			"/files/*",
+			"/files/readme.txt",
+			"/users/:id",
+			"/users/:id/settings",
+		},
+	}
+
+	paths := []string{
+		"", "/", "//", "/api", "/api/", "/api/posts", "/api/users", "/api/users/",
+		"/api/users/123", "/api/users/me", "/api/users/:id", "/api/users/123/posts",
+		"/api/users/123/posts/456", "/api/users/123/comments", "/files", "/files/",
+		"/files/readme.txt", "/files/a/b/c.txt", "/healthz", "/users/abc",
+		"/users/abc/settings", "/users/abc/settings/", "/nonexistent", "api/users",
+	}
+
+	for name, routes := range routeSets {
+		for _, layout := range []struct {
+			name string
+			opts []Option
+		}{
+			{"SharedTree", nil},
+			{"PerMethodTrees", []Option{WithPerMethodTrees()}},
+		} {
+			t.Run(name+"/"+layout.name, func(t *testing.T) {
+				rb := NewRouterBuilder(layout.opts...)
+				for _, path := range routes {
+					if err := rb.AddRoute("GET", path, &testHandler{name: path}); err != nil {
+						t.Fatalf("Error adding route %s: %v", path, err)
+					}
+				}
+				router, err := rb.Build()
+				if err != nil {
+					t.Fatalf("Error building router: %v", err)
+				}
+
+				for _, method := range []string{"GET", "POST"} {
+					for _, path := range paths {
+						wantHandler, wantParams := referenceMatch(router, method, path)
+
+						gotHandler, gotParams := router.Match(method, path)
+						if gotHandler != wantHandler {
+							t.Errorf("Match(%s, %q): got handler %v, reference returned %v", method, path, gotHandler, wantHandler)
+						} else if wantHandler != nil && !equalParams(gotParams, wantParams) {
+							t.Errorf("Match(%s, %q): got params %v, reference returned %v", method, path, gotParams, wantParams)
+						}
+						ReleaseParams(gotParams)
+					}
+				}
+			})
+		}
+	}
+}
+
+// equalParams compares params, treating nil and empty maps as equal
+func equalParams(a, b PathParams) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMatchZeroAlloc(t *testing.T) {
+	rb := NewRouterBuilder()
+	rb.AddRoute("GET", "/api/users", &testHandler{})
+	rb.AddRoute("GET", "/api/users/:id", &testHandler{})
+	rb.AddRoute("GET", "/api/users/:id/posts/:postId", &testHandler{})
+	rb.AddRoute("GET", "/static/*", &testHandler{})
+	router, err := rb.Build()
+	if err != nil {
+		t.Fatalf("Error building router: %v", err)
+	}
+
+	for _, path := range []string{"/api/users", "/api/users/123", "/api/users/123/posts/456", "/static/css/site.css", "/missing"} {
+		allocs := testing.AllocsPerRun(100, func() {
+			_, params := router.Match("GET", path)
+			ReleaseParams(params)
+		})
+		if allocs != 0 {
+			t.Errorf("Match(GET, %s): expected 0 allocs/op, got %.1f", path, allocs)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package fastrouter
+
+import (
+	"net/http"
+	"sort"
+)
+
+// standardMethods are the HTTP methods stored in a node's fixed handler array.
+// The position in this list is the method's index.
+var standardMethods = [...]string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodConnect,
+	http.MethodOptions,
+	http.MethodTrace,
+}
+
+// methodCount is the number of standard methods with a fixed slot per node
+const methodCount = len(standardMethods)
+
+// noMethodIndex is the index of extension methods (PROPFIND, REPORT, ...)
+// which are stored in a node's overflow map instead of the fixed array
+const noMethodIndex = -1
+
+// anyMethod is the method of routes that answer every method, as ServeMux
+// patterns without a method do. They are registered for each standard method
+// and under anyMethod, which extension methods without a route of their own
+// fall back to.
+const anyMethod = ""
+
+// methodIndex resolves a method to its slot in node.handlers, or noMethodIndex
+// for extension methods. It is called once per request before the trie walk.
+func methodIndex(method string) int {
+	switch method {
+	case http.MethodGet:
+		return 0
+	case http.MethodHead:
+		return 1
+	case http.MethodPost:
+		return 2
+	case http.MethodPut:
+		return 3
+	case http.MethodPatch:
+		return 4
+	case http.MethodDelete:
+		return 5
+	case http.MethodConnect:
+		return 6
+	case http.MethodOptions:
+		return 7
+	case http.MethodTrace:
+		return 8
+	}
+	return noMethodIndex
+}
+
+// handler returns the handler registered on n for a resolved method
+func (n *node) handler(index int, method string) http.Handler {
+	if index != noMethodIndex {
+		return n.handlers[index]
+	}
+	return n.extraMethods[method] // nil map lookups are safe
+}
+
+// setHandler registers handler on n for method
+func (n *node) setHandler(method string, handler http.Handler) {
+	if index := methodIndex(method); index != noMethodIndex {
+		n.handlers[index] = handler
+		return
+	}
+
+	if n.extraMethods == nil {
+		n.extraMethods = make(map[string]http.Handler)
+	}
+	n.extraMethods[method] = handler
+}
+
+// routeCount returns the number of methods with a handler on n
+func (n *node) routeCount() int {
+	count := len(n.extraMethods)
+	for _, h := range n.handlers {
+		if h != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// methods returns the methods with a handler on n, standard methods first
+func (n *node) methods() []string {
+	var methods []string
+	for i, h := range n.handlers {
+		if h != nil {
+			methods = append(methods, standardMethods[i])
+		}
+	}
+	extra := make([]string, 0, len(n.extraMethods))
+	for method := range n.extraMethods {
+		if method != anyMethod {
+			extra = append(extra, method)
+		}
+	}
+	sort.Strings(extra)
+	return append(methods, extra...)
+}
+
+// WithPerMethodTrees makes Build construct one trie per HTTP method, as httprouter
+// does, instead of a single trie whose nodes hold a handler per method. The method
+// is resolved before the walk, so a request for a method with few routes misses
+// cheaply. 405 responses still work by probing the other method trees.
+func WithPerMethodTrees() Option {
+	return func(rb *RouterBuilder) {
+		rb.perMethodTrees = true
+	}
+}
+
+// tree returns the trie to walk for a resolved method, or nil when no route
+// is registered for the method in a per-method router
+func (r *Router) tree(index int, method string) *node {
+	if !r.perMethod {
+		return r.root
+	}
+	if index != noMethodIndex {
+		return r.trees[index]
+	}
+	return r.extraTrees[method]
+}
+
+// treeForRoute returns the trie a route with method is inserted into,
+// creating the method's trie on first use
+func (r *Router) treeForRoute(method string) *node {
+	if !r.perMethod {
+		return r.root
+	}
+
+	if index := methodIndex(method); index != noMethodIndex {
+		if r.trees[index] == nil {
+			r.trees[index] = newRootNode()
+		}
+		return r.trees[index]
+	}
+
+	if r.extraTrees == nil {
+		r.extraTrees = make(map[string]*node)
+	}
+	if r.extraTrees[method] == nil {
+		r.extraTrees[method] = newRootNode()
+	}
+	return r.extraTrees[method]
+}
+
+// roots returns every trie of the router
+func (r *Router) roots() []*node {
+	if !r.perMethod {
+		return []*node{r.root}
+	}
+
+	roots := make([]*node, 0, methodCount+len(r.extraTrees))
+	for _, root := range r.trees {
+		if root != nil {
+			roots = append(roots, root)
+		}
+	}
+	for _, root := range r.extraTrees {
+		roots = append(roots, root)
+	}
+	return roots
+}
+
+// AllowedMethods returns the methods with a route matching path, sorted, as
+// used for the Allow header of a 405 response. The shared trie is walked once,
+// collecting the methods of every leaf the path reaches; per-method tries are
+// probed one by one. A path no route matches allocates nothing.
+func (r *Router) AllowedMethods(path string) []string {
+	if r.perMethod {
+		var allowed []string
+		for _, method := range r.methods {
+			if handler, params := r.Match(method, path); handler != nil {
+				allowed = append(allowed, method)
+				ReleaseParams(params)
+			}
+		}
+		return allowed
+	}
+
+	if path == "" || path[0] != '/' {
+		path = "/" + path
+	}
+	start := 1
+	if len(path) == 1 {
+		start = 2
+	}
+	allowed := collectMethods(r.root, path, start, nil)
+	sort.Strings(allowed)
+	return allowed
+}
+
+// collectMethods walks every branch of the trie that match would try for the
+// segment of path beginning at start, and adds the methods of the leaves
+// reached to allowed
+func collectMethods(n *node, path string, start int, allowed []string) []string {
+	if start > len(path) {
+		return n.appendMethods(allowed)
+	}
+
+	end := start
+	for end < len(path) && path[end] != '/' {
+		end++
+	}
+
+	if child, exists := n.children[path[start:end]]; exists {
+		allowed = collectMethods(child, path, end+1, allowed)
+	}
+	for _, child := range n.paramChild {
+		allowed = collectMethods(child, path, end+1, allowed)
+	}
+	if n.wildChild != nil {
+		allowed = n.wildChild.appendMethods(allowed)
+	}
+	return allowed
+}
+
+// appendMethods adds the methods with a handler on n that are not in
+// methods yet
+func (n *node) appendMethods(methods []string) []string {
+	for i, h := range n.handlers {
+		if h != nil {
+			methods = appendMethod(methods, standardMethods[i])
+		}
+	}
+	for method := range n.extraMethods {
+		if method != anyMethod {
+			methods = appendMethod(methods, method)
+		}
+	}
+	return methods
+}
+
+// appendMethod adds method to methods unless it is there already
+func appendMethod(methods []string, method string) []string {
+	for _, m := range methods {
+		if m == method {
+			return methods
+		}
+	}
+	return append(methods, method)
+}
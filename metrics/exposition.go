@@ -0,0 +1,158 @@
+package metrics
+
+import (
+	"bufio"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// contentType is the media type of the Prometheus text exposition format
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// snapshot is a copy of one series, taken so writing does not hold its lock
+type snapshot struct {
+	labels
+	count    uint64
+	duration histogram
+	size     histogram
+}
+
+// Handler returns an http.Handler that writes the collected metrics in the
+// Prometheus text exposition format
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		bw := bufio.NewWriter(w)
+		m.writeTo(bw)
+		bw.Flush()
+	})
+}
+
+// writeTo writes every metric family, with series sorted by label
+func (m *Metrics) writeTo(w *bufio.Writer) {
+	snapshots := m.snapshot()
+
+	name := m.name("http_requests_total")
+	writeHeader(w, name, "counter", "Total number of HTTP requests by method, route pattern and status class.")
+	for _, s := range snapshots {
+		writeSample(w, name, s.labels, "", "", float64(s.count))
+	}
+
+	name = m.name("http_request_duration_seconds")
+	writeHeader(w, name, "histogram", "Latency of HTTP requests in seconds.")
+	for _, s := range snapshots {
+		writeHistogram(w, name, s.labels, m.durationBuckets, s.duration, s.count)
+	}
+
+	name = m.name("http_response_size_bytes")
+	writeHeader(w, name, "histogram", "Size of HTTP response bodies in bytes.")
+	for _, s := range snapshots {
+		writeHistogram(w, name, s.labels, m.sizeBuckets, s.size, s.count)
+	}
+
+	name = m.name("http_requests_in_flight")
+	writeHeader(w, name, "gauge", "Number of HTTP requests being served.")
+	w.WriteString(name)
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(float64(m.inFlight.Load())))
+	w.WriteByte('\n')
+}
+
+// snapshot copies every series, sorted by method, route and status
+func (m *Metrics) snapshot() []snapshot {
+	m.mu.RLock()
+	snapshots := make([]snapshot, 0, len(m.series))
+	for l, s := range m.series {
+		s.mu.Lock()
+		snapshots = append(snapshots, snapshot{
+			labels:   l,
+			count:    s.count,
+			duration: histogram{counts: append([]uint64(nil), s.duration.counts...), sum: s.duration.sum},
+			size:     histogram{counts: append([]uint64(nil), s.size.counts...), sum: s.size.sum},
+		})
+		s.mu.Unlock()
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		a, b := snapshots[i].labels, snapshots[j].labels
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.status < b.status
+	})
+	return snapshots
+}
+
+// name returns a metric name with the namespace prefix
+func (m *Metrics) name(name string) string {
+	if m.namespace == "" {
+		return name
+	}
+	return m.namespace + "_" + name
+}
+
+// writeHeader writes the HELP and TYPE lines of a metric family
+func writeHeader(w *bufio.Writer, name, typ, help string) {
+	w.WriteString("# HELP " + name + " " + help + "\n")
+	w.WriteString("# TYPE " + name + " " + typ + "\n")
+}
+
+// writeHistogram writes the cumulative buckets, sum and count of h
+func writeHistogram(w *bufio.Writer, name string, l labels, buckets []float64, h histogram, count uint64) {
+	var cumulative uint64
+	for i, bound := range buckets {
+		if i < len(h.counts) {
+			cumulative += h.counts[i]
+		}
+		writeSample(w, name+"_bucket", l, "le", formatFloat(bound), float64(cumulative))
+	}
+	writeSample(w, name+"_bucket", l, "le", "+Inf", float64(count))
+	writeSample(w, name+"_sum", l, "", "", h.sum)
+	writeSample(w, name+"_count", l, "", "", float64(count))
+}
+
+// writeSample writes one sample line, with an optional extra label such as le
+func writeSample(w *bufio.Writer, name string, l labels, extraName, extraValue string, value float64) {
+	w.WriteString(name)
+	w.WriteString(`{method="`)
+	w.WriteString(escapeLabel(l.method))
+	w.WriteString(`",route="`)
+	w.WriteString(escapeLabel(l.route))
+	w.WriteString(`",status="`)
+	w.WriteString(escapeLabel(l.status))
+	if extraName != "" {
+		w.WriteString(`",` + extraName + `="`)
+		w.WriteString(extraValue)
+	}
+	w.WriteString(`"} `)
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+// labelEscaper escapes a label value as the exposition format requires
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabel escapes backslashes, double quotes and newlines in v
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+// formatFloat formats v as the exposition format expects
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
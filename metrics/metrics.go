@@ -0,0 +1,199 @@
+// Package metrics records request metrics for a fastrouter.Router and exposes
+// them in the Prometheus text exposition format, without depending on the
+// Prometheus client library.
+//
+//	m := metrics.New()
+//	http.Handle("/metrics", m.Handler())
+//	http.Handle("/", m.Middleware(router))
+//
+// Requests are labeled by method, matched route pattern and status class
+// ("2xx", "4xx", ...). Requests no route matched share the fastrouter.Unmatched
+// route label, so unknown paths cannot grow the number of series.
+package metrics
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jamra/fastrouter"
+	"github.com/jamra/fastrouter/internal/respwriter"
+)
+
+// DefaultDurationBuckets are the upper bounds, in seconds, of the latency
+// histogram. They match the Prometheus client defaults.
+var DefaultDurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// DefaultSizeBuckets are the upper bounds, in bytes, of the response size
+// histogram
+var DefaultSizeBuckets = []float64{100, 1000, 10000, 100000, 1e6, 1e7}
+
+// Metrics collects request metrics. It is safe for concurrent use.
+type Metrics struct {
+	namespace       string
+	durationBuckets []float64
+	sizeBuckets     []float64
+
+	inFlight atomic.Int64
+
+	mu     sync.RWMutex
+	series map[labels]*series
+}
+
+// Option configures Metrics
+type Option func(*Metrics)
+
+// WithNamespace sets the prefix of the metric names, "fastrouter" by default.
+// An empty namespace drops the prefix.
+func WithNamespace(namespace string) Option {
+	return func(m *Metrics) {
+		m.namespace = namespace
+	}
+}
+
+// WithDurationBuckets sets the upper bounds, in seconds, of the latency histogram
+func WithDurationBuckets(buckets ...float64) Option {
+	return func(m *Metrics) {
+		m.durationBuckets = sortedBuckets(buckets)
+	}
+}
+
+// WithSizeBuckets sets the upper bounds, in bytes, of the response size histogram
+func WithSizeBuckets(buckets ...float64) Option {
+	return func(m *Metrics) {
+		m.sizeBuckets = sortedBuckets(buckets)
+	}
+}
+
+// New returns an empty Metrics
+func New(opts ...Option) *Metrics {
+	m := &Metrics{
+		namespace:       "fastrouter",
+		durationBuckets: DefaultDurationBuckets,
+		sizeBuckets:     DefaultSizeBuckets,
+		series:          make(map[labels]*series),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// labels identify one series
+type labels struct {
+	method string
+	route  string
+	status string
+}
+
+// series holds the counters and histograms of one label set
+type series struct {
+	mu       sync.Mutex
+	count    uint64
+	duration histogram
+	size     histogram
+}
+
+// histogram counts observations per bucket. counts[i] is the number of
+// observations in bucket i alone; the cumulative counts Prometheus expects
+// are summed when writing.
+type histogram struct {
+	counts []uint64 // one per bucket, plus +Inf
+	sum    float64
+}
+
+// observe adds v to the histogram with the given bucket bounds
+func (h *histogram) observe(buckets []float64, v float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(buckets)+1)
+	}
+	i := sort.SearchFloat64s(buckets, v)
+	h.counts[i]++
+	h.sum += v
+}
+
+// Middleware returns next wrapped to record a request count, latency and
+// response size per request, and the number of requests in flight. The route
+// label is read from fastrouter.RouteFromContext, so next should be a
+// *fastrouter.Router or a handler that calls one with the same request.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.inFlight.Add(1)
+		defer m.inFlight.Add(-1)
+
+		start := time.Now()
+		r = fastrouter.RecordRoute(r)
+		rw := respwriter.Wrap(w)
+		next.ServeHTTP(rw, r)
+
+		route := fastrouter.Unmatched
+		if info := fastrouter.RouteFromContext(r.Context()); info != nil {
+			route = info.Pattern
+		}
+		m.observe(labels{
+			method: methodLabel(r.Method),
+			route:  route,
+			status: statusClass(rw.Status),
+		}, time.Since(start).Seconds(), float64(rw.Size))
+	})
+}
+
+// observe records one finished request
+func (m *Metrics) observe(l labels, seconds, size float64) {
+	m.mu.RLock()
+	s := m.series[l]
+	m.mu.RUnlock()
+	if s == nil {
+		m.mu.Lock()
+		if s = m.series[l]; s == nil {
+			s = &series{}
+			m.series[l] = s
+		}
+		m.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	s.count++
+	s.duration.observe(m.durationBuckets, seconds)
+	s.size.observe(m.sizeBuckets, size)
+	s.mu.Unlock()
+}
+
+// methodLabel returns method for the standard methods and "OTHER" for any
+// other, so arbitrary request methods cannot grow the number of series
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodConnect,
+		http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "OTHER"
+}
+
+// statusClass returns the class of a status code, such as "2xx". A handler
+// that wrote nothing answered 200.
+func statusClass(status int) string {
+	switch {
+	case status == 0:
+		return "2xx"
+	case status < 200:
+		return "1xx"
+	case status < 300:
+		return "2xx"
+	case status < 400:
+		return "3xx"
+	case status < 500:
+		return "4xx"
+	}
+	return "5xx"
+}
+
+// sortedBuckets returns a sorted copy of buckets
+func sortedBuckets(buckets []float64) []float64 {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	return sorted
+}
@@ -0,0 +1,160 @@
+package metrics_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jamra/fastrouter"
+	"github.com/jamra/fastrouter/metrics"
+)
+
+func newTestRouter(t *testing.T) *fastrouter.Router {
+	t.Helper()
+	rb := fastrouter.NewRouterBuilder()
+	rb.AddRoute("GET", "/boom", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	rb.AddRoute("GET", "/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "hello")
+	}))
+	rb.AddRoute("POST", "/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	router, err := rb.Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	return router
+}
+
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	return rec.Body.String()
+}
+
+func TestMiddleware(t *testing.T) {
+	m := metrics.New()
+	handler := m.Middleware(newTestRouter(t))
+
+	requests := []struct {
+		method, path string
+	}{
+		{"GET", "/users/1"},
+		{"GET", "/users/2"},
+		{"POST", "/users/3"},
+		{"GET", "/boom"},
+		{"GET", "/missing"},
+		{"GET", "/also/missing"},
+		{"DELETE", "/users/4"},
+		{"BREW", "/users/5"},
+	}
+	for _, req := range requests {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.path, nil))
+	}
+
+	body := scrape(t, m)
+	for _, want := range []string{
+		"# TYPE fastrouter_http_requests_total counter\n",
+		`fastrouter_http_requests_total{method="GET",route="/users/:id",status="2xx"} 2` + "\n",
+		`fastrouter_http_requests_total{method="POST",route="/users/:id",status="2xx"} 1` + "\n",
+		`fastrouter_http_requests_total{method="GET",route="/boom",status="5xx"} 1` + "\n",
+		`fastrouter_http_requests_total{method="GET",route="<unmatched>",status="4xx"} 2` + "\n",
+		`fastrouter_http_requests_total{method="DELETE",route="<unmatched>",status="4xx"} 1` + "\n",
+		`fastrouter_http_requests_total{method="OTHER",route="<unmatched>",status="4xx"} 1` + "\n",
+		"# TYPE fastrouter_http_request_duration_seconds histogram\n",
+		`fastrouter_http_request_duration_seconds_bucket{method="GET",route="/users/:id",status="2xx",le="+Inf"} 2` + "\n",
+		`fastrouter_http_request_duration_seconds_count{method="GET",route="/users/:id",status="2xx"} 2` + "\n",
+		"# TYPE fastrouter_http_response_size_bytes histogram\n",
+		`fastrouter_http_response_size_bytes_bucket{method="GET",route="/users/:id",status="2xx",le="100"} 2` + "\n",
+		`fastrouter_http_response_size_bytes_sum{method="GET",route="/users/:id",status="2xx"} 10` + "\n",
+		`fastrouter_http_response_size_bytes_bucket{method="POST",route="/users/:id",status="2xx",le="100"} 1` + "\n",
+		"# TYPE fastrouter_http_requests_in_flight gauge\n",
+		"fastrouter_http_requests_in_flight 0\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("exposition missing %q\n%s", want, body)
+		}
+	}
+}
+
+func TestInFlight(t *testing.T) {
+	m := metrics.New(metrics.WithNamespace("app"))
+	entered := make(chan struct{})
+	release := make(chan struct{})
+
+	rb := fastrouter.NewRouterBuilder()
+	rb.AddRoute("GET", "/slow", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+	}))
+	router, err := rb.Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		m.Middleware(router).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/slow", nil))
+		close(done)
+	}()
+
+	<-entered
+	if body := scrape(t, m); !strings.Contains(body, "app_http_requests_in_flight 1\n") {
+		t.Errorf("expected one request in flight\n%s", body)
+	}
+	close(release)
+	<-done
+	if body := scrape(t, m); !strings.Contains(body, "app_http_requests_in_flight 0\n") {
+		t.Errorf("expected no request in flight\n%s", body)
+	}
+}
+
+func TestBuckets(t *testing.T) {
+	m := metrics.New(metrics.WithSizeBuckets(10, 1), metrics.WithDurationBuckets(60))
+	m.Middleware(newTestRouter(t)).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+
+	body := scrape(t, m)
+	for _, want := range []string{
+		`fastrouter_http_response_size_bytes_bucket{method="GET",route="/users/:id",status="2xx",le="1"} 0` + "\n",
+		`fastrouter_http_response_size_bytes_bucket{method="GET",route="/users/:id",status="2xx",le="10"} 1` + "\n",
+		`fastrouter_http_request_duration_seconds_bucket{method="GET",route="/users/:id",status="2xx",le="60"} 1` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("exposition missing %q\n%s", want, body)
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	m := metrics.New()
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.Handler())
+	mux.Handle("/", m.Middleware(newTestRouter(t)))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/users/7")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	resp.Body.Close()
+
+	resp, err = http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	want := `fastrouter_http_requests_total{method="GET",route="/users/:id",status="2xx"} 1`
+	if !strings.Contains(string(body), want) {
+		t.Errorf("exposition missing %q\n%s", want, body)
+	}
+}
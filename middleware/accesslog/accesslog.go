@@ -0,0 +1,201 @@
+// Package accesslog logs one slog record per request served by a
+// fastrouter.Router.
+//
+//	logs := accesslog.New(accesslog.WithRedactedParams("token"))
+//	http.ListenAndServe(":8080", logs.Middleware(router))
+//
+// Each record carries the method, path, route pattern (fastrouter.Unmatched
+// when no route matched), path params, status, response bytes, duration,
+// remote address and request ID.
+package accesslog
+
+import (
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jamra/fastrouter"
+	"github.com/jamra/fastrouter/internal/respwriter"
+)
+
+// Redacted replaces the value of redacted params in the path and params
+const Redacted = "[REDACTED]"
+
+// Logger writes access log records. It is safe for concurrent use.
+type Logger struct {
+	logger *slog.Logger
+	level  slog.Level
+	sample func(r *http.Request, status int) bool
+	redact map[string]bool
+}
+
+// Option configures a Logger
+type Option func(*Logger)
+
+// WithLogger sets the slog.Logger records are written to, slog.Default() by
+// default
+func WithLogger(logger *slog.Logger) Option {
+	return func(l *Logger) {
+		l.logger = logger
+	}
+}
+
+// WithLevel sets the level of records, slog.LevelInfo by default. Responses
+// with a 5xx status are always logged at slog.LevelError.
+func WithLevel(level slog.Level) Option {
+	return func(l *Logger) {
+		l.level = level
+	}
+}
+
+// WithSampler logs a request only if sample returns true. It is called after
+// the handler, with the response status.
+func WithSampler(sample func(r *http.Request, status int) bool) Option {
+	return func(l *Logger) {
+		l.sample = sample
+	}
+}
+
+// WithSampleRate logs a random fraction rate of the successful requests.
+// Responses with a 4xx or 5xx status are always logged.
+func WithSampleRate(rate float64) Option {
+	return WithSampler(func(r *http.Request, status int) bool {
+		return status >= 400 || rand.Float64() < rate
+	})
+}
+
+// WithRedactedParams replaces the values of the named path params with
+// Redacted, both in the params and in the logged path. A wildcard is named
+// "*" unless the route names it, as in "/files/*path".
+func WithRedactedParams(names ...string) Option {
+	return func(l *Logger) {
+		if l.redact == nil {
+			l.redact = make(map[string]bool)
+		}
+		for _, name := range names {
+			l.redact[name] = true
+		}
+	}
+}
+
+// New returns a Logger
+func New(opts ...Option) *Logger {
+	l := &Logger{level: slog.LevelInfo}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Middleware returns next wrapped to log every request. The route and params
+// are read through fastrouter.RouteFromContext and RouteParamsFromContext, so
+// next should be a *fastrouter.Router or a handler that calls one with the
+// same request.
+func (l *Logger) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		r = fastrouter.RecordRoute(r)
+		rw := respwriter.Wrap(w)
+		next.ServeHTTP(rw, r)
+		l.log(r, rw, time.Since(start))
+	})
+}
+
+// log writes the record of a served request
+func (l *Logger) log(r *http.Request, rw *respwriter.Writer, duration time.Duration) {
+	status := rw.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if l.sample != nil && !l.sample(r, status) {
+		return
+	}
+	logger := l.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	level := l.level
+	if status >= 500 {
+		level = slog.LevelError
+	}
+	ctx := r.Context()
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	path := r.URL.Path
+	route := fastrouter.Unmatched
+	var params []any
+	if info := fastrouter.RouteFromContext(ctx); info != nil {
+		route = info.Pattern
+		path, params = l.extract(path, fastrouter.RouteParamsFromContext(ctx))
+	}
+
+	logger.LogAttrs(ctx, level, "http request",
+		slog.String("method", r.Method),
+		slog.String("path", path),
+		slog.String("route", route),
+		slog.Group("params", params...),
+		slog.Int("status", status),
+		slog.Int64("bytes", rw.Size),
+		slog.Duration("duration", duration),
+		slog.String("remote_addr", r.RemoteAddr),
+		slog.String("request_id", requestID(r, rw)),
+	)
+}
+
+// extract returns the params as attributes, sorted by name, and path with
+// the values of redacted params replaced. The params are the ones the router
+// matched, recorded before it released them, so they hold for mounted
+// handlers and escaped paths alike.
+func (l *Logger) extract(path string, params fastrouter.PathParams) (string, []any) {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	attrs := make([]any, 0, len(names))
+	for _, name := range names {
+		value := params[name]
+		if l.redact[name] {
+			path = redact(path, value)
+			value = Redacted
+		}
+		attrs = append(attrs, slog.String(name, value))
+	}
+	return path, attrs
+}
+
+// redact replaces value in path wherever it spans whole segments. A static
+// segment equal to the value is replaced too, which hides more than needed
+// but never leaves the value in clear.
+func redact(path, value string) string {
+	if value == "" {
+		return path
+	}
+	segments := strings.Split(path, "/")
+	want := strings.Split(value, "/")
+	for i := 0; i+len(want) <= len(segments); i++ {
+		if slices.Equal(segments[i:i+len(want)], want) {
+			segments = append(segments[:i+1], segments[i+len(want):]...)
+			segments[i] = Redacted
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// requestID returns the ID of the request: the one fastrouter.RequestID gave
+// it, or else the one echoed in the response or sent by the client
+func requestID(r *http.Request, rw *respwriter.Writer) string {
+	if id := fastrouter.GetRequestID(r); id != "" {
+		return id
+	}
+	if id := rw.Header().Get(fastrouter.RequestIDHeader); id != "" {
+		return id
+	}
+	return r.Header.Get(fastrouter.RequestIDHeader)
+}
@@ -0,0 +1,183 @@
+package accesslog_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jamra/fastrouter"
+	"github.com/jamra/fastrouter/middleware/accesslog"
+)
+
+func newTestRouter(t *testing.T) *fastrouter.Router {
+	t.Helper()
+	rb := fastrouter.NewRouterBuilder()
+	rb.AddRoute("GET", "/boom", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	rb.AddRoute("GET", "/files/*path", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "file")
+	}))
+	rb.AddRoute("GET", "/reset/{token}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-ID", "req-1")
+		io.WriteString(w, "reset")
+	}))
+	rb.AddRoute("GET", "/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "hello")
+	}))
+	router, err := rb.Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	return router
+}
+
+// serve runs one request through the middleware and decodes the records
+func serve(t *testing.T, router http.Handler, opts []accesslog.Option, req *http.Request) []map[string]any {
+	t.Helper()
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	handler := accesslog.New(append([]accesslog.Option{accesslog.WithLogger(logger)}, opts...)...).Middleware(router)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var records []map[string]any
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var record map[string]any
+		if err := dec.Decode(&record); err != nil {
+			t.Fatalf("Bad record: %v", err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestRecord(t *testing.T) {
+	router := newTestRouter(t)
+	req := httptest.NewRequest("GET", "/users/42", nil)
+	req.Header.Set("X-Request-ID", "abc")
+	records := serve(t, router, nil, req)
+	if len(records) != 1 {
+		t.Fatalf("Expected one record, got %d", len(records))
+	}
+	record := records[0]
+	for key, want := range map[string]any{
+		"level":       "INFO",
+		"msg":         "http request",
+		"method":      "GET",
+		"path":        "/users/42",
+		"route":       "/users/:id",
+		"status":      float64(200),
+		"bytes":       float64(5),
+		"remote_addr": "192.0.2.1:1234",
+		"request_id":  "abc",
+	} {
+		if record[key] != want {
+			t.Errorf("%s = %v, want %v", key, record[key], want)
+		}
+	}
+	if params, _ := record["params"].(map[string]any); params["id"] != "42" {
+		t.Errorf("params = %v, want id=42", record["params"])
+	}
+	if _, ok := record["duration"]; !ok {
+		t.Error("Expected a duration")
+	}
+
+	record = serve(t, router, nil, httptest.NewRequest("GET", "/missing", nil))[0]
+	if record["route"] != fastrouter.Unmatched || record["status"] != float64(404) {
+		t.Errorf("Expected an unmatched 404, got %v", record)
+	}
+
+	record = serve(t, router, nil, httptest.NewRequest("GET", "/boom", nil))[0]
+	if record["level"] != "ERROR" {
+		t.Errorf("Expected a 5xx to log at ERROR, got %v", record["level"])
+	}
+}
+
+func TestRedaction(t *testing.T) {
+	router := newTestRouter(t)
+	redact := []accesslog.Option{accesslog.WithRedactedParams("token", "path")}
+
+	record := serve(t, router, redact, httptest.NewRequest("GET", "/reset/s3cret", nil))[0]
+	if record["path"] != "/reset/[REDACTED]" {
+		t.Errorf("path = %v", record["path"])
+	}
+	if params, _ := record["params"].(map[string]any); params["token"] != accesslog.Redacted {
+		t.Errorf("params = %v", record["params"])
+	}
+	if record["request_id"] != "req-1" {
+		t.Errorf("Expected the echoed request ID, got %v", record["request_id"])
+	}
+
+	record = serve(t, router, redact, httptest.NewRequest("GET", "/files/a/b.txt", nil))[0]
+	if record["path"] != "/files/[REDACTED]" {
+		t.Errorf("path = %v", record["path"])
+	}
+
+	record = serve(t, router, nil, httptest.NewRequest("GET", "/files/a/b.txt", nil))[0]
+	if params, _ := record["params"].(map[string]any); params["path"] != "a/b.txt" {
+		t.Errorf("params = %v", record["params"])
+	}
+}
+
+func TestRedactionUnderMount(t *testing.T) {
+	// A plain handler wrapping a router is mounted as is, not grafted, so the
+	// inner router matches a path the pattern does not line up with
+	inner := newTestRouter(t)
+	rb := fastrouter.NewRouterBuilder()
+	rb.Mount("/tenants/{tenant}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inner.ServeHTTP(w, r)
+	}))
+	router, err := rb.Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	redact := []accesslog.Option{accesslog.WithRedactedParams("token")}
+	record := serve(t, router, redact, httptest.NewRequest("GET", "/tenants/acme/reset/s3cret", nil))[0]
+	if record["path"] != "/tenants/acme/reset/[REDACTED]" {
+		t.Errorf("path = %v", record["path"])
+	}
+	if params, _ := record["params"].(map[string]any); params["token"] != accesslog.Redacted || params["tenant"] != "acme" {
+		t.Errorf("params = %v", record["params"])
+	}
+	if record["route"] != "/tenants/{tenant}/reset/{token}" {
+		t.Errorf("route = %v", record["route"])
+	}
+}
+
+func TestSampling(t *testing.T) {
+	router := newTestRouter(t)
+	none := []accesslog.Option{accesslog.WithSampleRate(0)}
+
+	if records := serve(t, router, none, httptest.NewRequest("GET", "/users/1", nil)); len(records) != 0 {
+		t.Errorf("Expected successes to be sampled out, got %v", records)
+	}
+	if records := serve(t, router, none, httptest.NewRequest("GET", "/missing", nil)); len(records) != 1 {
+		t.Errorf("Expected errors to always be logged, got %d records", len(records))
+	}
+	all := []accesslog.Option{accesslog.WithSampleRate(1)}
+	if records := serve(t, router, all, httptest.NewRequest("GET", "/users/1", nil)); len(records) != 1 {
+		t.Errorf("Expected every request to be logged, got %d records", len(records))
+	}
+}
+
+func TestRequestIDFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	logs := accesslog.New(accesslog.WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+	handler := fastrouter.RequestID(logs.Middleware(newTestRouter(t)))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/users/1", nil))
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Bad record: %v", err)
+	}
+	if id := w.Header().Get(fastrouter.RequestIDHeader); id == "" || record["request_id"] != id {
+		t.Errorf("Expected request_id %q, got %v", id, record["request_id"])
+	}
+}
@@ -0,0 +1,439 @@
+// Package compress compresses responses with the best encoding a client
+// accepts, gzip and deflate built in and others such as zstd or br pluggable.
+// Attach it to a whole router, or to routes and groups with
+// fastrouter.WithMiddleware so routes serving already compressed content
+// can go without:
+//
+//	c := compress.New(compress.WithMinSize(1024))
+//	api := rb.Group("/api", fastrouter.WithMiddleware(c.Middleware))
+//
+// Responses that already carry a Content-Encoding, such as the precompressed
+// files of RouterBuilder.ServeFiles, are passed through untouched.
+package compress
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Encoder is a pooled compressing writer. *gzip.Writer and *zlib.Writer
+// implement it, as do the writers of most third-party compressors.
+type Encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// DefaultContentTypes are the media types compressed by default. A type
+// ending in "/*" stands for every subtype.
+var DefaultContentTypes = []string{
+	"text/*",
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"application/xhtml+xml",
+	"application/wasm",
+	"image/svg+xml",
+}
+
+// DefaultMinSize is the smallest response body compressed by default, in bytes
+const DefaultMinSize = 1024
+
+// encoding is a negotiable content coding with a pool of its encoders
+type encoding struct {
+	name string
+	pool sync.Pool
+}
+
+// Compressor negotiates and applies response compression
+type Compressor struct {
+	minSize   int
+	level     int
+	types     map[string]bool
+	prefixes  []string // from types ending in "/*", with the slash
+	custom    []*encoding
+	order     []string
+	encodings []*encoding // in order of server preference
+}
+
+// Option configures a Compressor
+type Option func(*Compressor)
+
+// WithMinSize sets the smallest response body compressed, DefaultMinSize by
+// default. Smaller bodies are sent as they are.
+func WithMinSize(n int) Option {
+	return func(c *Compressor) {
+		c.minSize = n
+	}
+}
+
+// WithContentTypes sets the media types compressed, DefaultContentTypes by
+// default. A type ending in "/*" stands for every subtype.
+func WithContentTypes(types ...string) Option {
+	return func(c *Compressor) {
+		c.types = make(map[string]bool)
+		c.prefixes = nil
+		for _, t := range types {
+			t = strings.ToLower(t)
+			if prefix, ok := strings.CutSuffix(t, "/*"); ok {
+				c.prefixes = append(c.prefixes, prefix+"/")
+				continue
+			}
+			c.types[t] = true
+		}
+	}
+}
+
+// WithLevel sets the gzip and deflate compression level, such as
+// gzip.BestSpeed, gzip.DefaultCompression by default
+func WithLevel(level int) Option {
+	return func(c *Compressor) {
+		c.level = level
+	}
+}
+
+// WithEncoder adds a content coding, such as "zstd" or "br", preferred over
+// gzip and deflate when a client accepts it as much. newEncoder creates the
+// encoders, which are pooled and Reset for each response.
+func WithEncoder(name string, newEncoder func(w io.Writer) Encoder) Option {
+	return func(c *Compressor) {
+		e := &encoding{name: strings.ToLower(name)}
+		e.pool.New = func() any { return newEncoder(nil) }
+		c.custom = append(c.custom, e)
+	}
+}
+
+// WithEncodings restricts the content codings to names, in order of
+// preference, such as "gzip" alone
+func WithEncodings(names ...string) Option {
+	return func(c *Compressor) {
+		c.order = make([]string, len(names))
+		for i, name := range names {
+			c.order[i] = strings.ToLower(name)
+		}
+	}
+}
+
+// New returns a Compressor. It panics if the compression level is invalid.
+func New(opts ...Option) *Compressor {
+	c := &Compressor{minSize: DefaultMinSize, level: gzip.DefaultCompression}
+	WithContentTypes(DefaultContentTypes...)(c)
+	for _, opt := range opts {
+		opt(c)
+	}
+	if _, err := gzip.NewWriterLevel(nil, c.level); err != nil {
+		panic("compress: " + err.Error())
+	}
+
+	gz := &encoding{name: "gzip"}
+	gz.pool.New = func() any {
+		w, _ := gzip.NewWriterLevel(nil, c.level)
+		return w
+	}
+	// HTTP's "deflate" coding is the zlib format, not raw DEFLATE (RFC 9110 8.4.1.2)
+	deflate := &encoding{name: "deflate"}
+	deflate.pool.New = func() any {
+		w, _ := zlib.NewWriterLevel(nil, c.level)
+		return w
+	}
+	available := append(c.custom, gz, deflate)
+
+	if c.order == nil {
+		c.encodings = available
+		return c
+	}
+	for _, name := range c.order {
+		for _, e := range available {
+			if e.name == name {
+				c.encodings = append(c.encodings, e)
+				break
+			}
+		}
+	}
+	return c
+}
+
+// Middleware returns next with its responses compressed for clients that
+// accept one of the compressor's encodings
+func (c *Compressor) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e := c.negotiate(r.Header.Get("Accept-Encoding"))
+		if e == nil || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := acquireWriter(w, c, e)
+		finished := false
+		defer func() {
+			// A panicking handler's buffered output is dropped, so the
+			// router can still answer with a clean 500
+			if finished {
+				cw.close()
+			} else {
+				cw.discard()
+			}
+			releaseWriter(cw)
+		}()
+		next.ServeHTTP(cw, r)
+		finished = true
+	})
+}
+
+// negotiate returns the encoding the client prefers most, favouring the
+// server's order among equals, or nil if it accepts none
+func (c *Compressor) negotiate(accept string) *encoding {
+	if accept == "" {
+		return nil
+	}
+
+	var best *encoding
+	bestQ := 0.0
+	for _, e := range c.encodings {
+		if q := quality(accept, e.name); q > bestQ {
+			best, bestQ = e, q
+		}
+	}
+	return best
+}
+
+// quality returns the q-value accept gives coding, falling back to that of
+// "*", and 0 if neither is listed
+func quality(accept, coding string) float64 {
+	q, wildcard := -1.0, -1.0
+	for _, part := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != coding && name != "*" {
+			continue
+		}
+
+		value := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, v, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					value = parsed
+				}
+			}
+		}
+		if name == coding {
+			q = value
+		} else {
+			wildcard = value
+		}
+	}
+	if q >= 0 {
+		return q
+	}
+	return max(wildcard, 0)
+}
+
+// compressible reports whether responses of contentType are compressed
+func (c *Compressor) compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if c.types[mediaType] {
+		return true
+	}
+	for _, prefix := range c.prefixes {
+		if strings.HasPrefix(mediaType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// writerPool holds response writers, with their buffers, across requests
+var writerPool = sync.Pool{
+	New: func() any {
+		return &writer{}
+	},
+}
+
+// writer buffers the start of a response until it knows whether to compress
+// it: once minSize bytes are written, or when the handler flushes or returns.
+type writer struct {
+	http.ResponseWriter
+	c        *Compressor
+	encoding *encoding
+	encoder  Encoder
+
+	buf         []byte
+	status      int
+	decided     bool
+	compressing bool
+}
+
+func acquireWriter(w http.ResponseWriter, c *Compressor, e *encoding) *writer {
+	cw := writerPool.Get().(*writer)
+	cw.ResponseWriter, cw.c, cw.encoding = w, c, e
+	return cw
+}
+
+func releaseWriter(cw *writer) {
+	*cw = writer{buf: cw.buf[:0]}
+	writerPool.Put(cw)
+}
+
+// WriteHeader records the status, which is sent once the compression is
+// decided. Informational statuses are sent at once.
+func (cw *writer) WriteHeader(code int) {
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		cw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if cw.decided || cw.status != 0 {
+		return
+	}
+	cw.status = code
+	if !bodyAllowed(code) {
+		cw.decide()
+	}
+}
+
+func (cw *writer) Write(b []byte) (int, error) {
+	if !cw.decided {
+		cw.buf = append(cw.buf, b...)
+		if len(cw.buf) < cw.c.minSize {
+			return len(b), nil
+		}
+		if err := cw.decide(); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if cw.compressing {
+		return cw.encoder.Write(b)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// decide sends the header, compressed or not, and the buffered body
+func (cw *writer) decide() error {
+	cw.decided = true
+	status := cw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	// A partial response's Content-Range counts identity bytes, so ranges are
+	// never compressed
+	header := cw.Header()
+	partial := status == http.StatusPartialContent || header.Get("Content-Range") != ""
+	if header.Get("Content-Encoding") == "" && bodyAllowed(status) && !partial {
+		contentType := header.Get("Content-Type")
+		if contentType == "" && len(cw.buf) > 0 {
+			contentType = http.DetectContentType(cw.buf)
+			header.Set("Content-Type", contentType)
+		}
+		if cw.c.compressible(contentType) {
+			header.Add("Vary", "Accept-Encoding")
+			cw.compressing = len(cw.buf) >= cw.c.minSize
+		}
+	}
+
+	if cw.compressing {
+		header.Del("Content-Length")
+		header.Del("Accept-Ranges")
+		header.Set("Content-Encoding", cw.encoding.name)
+		if etag := header.Get("ETag"); strings.HasPrefix(etag, `"`) {
+			header.Set("ETag", "W/"+etag) // the bytes differ from the identity response
+		}
+		cw.encoder = cw.encoding.pool.Get().(Encoder)
+		cw.encoder.Reset(cw.ResponseWriter)
+	}
+
+	cw.ResponseWriter.WriteHeader(status)
+	if len(cw.buf) == 0 {
+		return nil
+	}
+	var err error
+	if cw.compressing {
+		_, err = cw.encoder.Write(cw.buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(cw.buf)
+	}
+	cw.buf = cw.buf[:0]
+	return err
+}
+
+// close decides on a response shorter than minSize and finishes the
+// compressed stream
+func (cw *writer) close() {
+	if !cw.decided {
+		if cw.status == 0 && len(cw.buf) == 0 {
+			return // nothing written; the server sends its default response
+		}
+		cw.decide()
+	}
+	if cw.compressing {
+		cw.encoder.Close()
+		cw.releaseEncoder()
+	}
+}
+
+// discard drops the buffered output of a handler that panicked and returns
+// the encoder, without finishing its stream
+func (cw *writer) discard() {
+	cw.buf = cw.buf[:0]
+	if cw.compressing {
+		cw.releaseEncoder()
+	}
+}
+
+// releaseEncoder returns the encoder to its pool
+func (cw *writer) releaseEncoder() {
+	cw.encoder.Reset(nil)
+	cw.encoding.pool.Put(cw.encoder)
+	cw.encoder = nil
+}
+
+// Flush sends what was written so far. A response flushed before minSize
+// bytes were written is not compressed, so small streamed events go out as
+// they are.
+func (cw *writer) Flush() {
+	if !cw.decided {
+		cw.decide()
+	}
+	if cw.compressing {
+		cw.encoder.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack hands the connection over, if nothing was written yet
+func (cw *writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := cw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T does not implement http.Hijacker", cw.ResponseWriter)
+	}
+	if cw.decided || len(cw.buf) > 0 {
+		return nil, nil, fmt.Errorf("compress: cannot hijack after writing the response")
+	}
+	cw.decided = true
+	return hj.Hijack()
+}
+
+// Unwrap returns the underlying ResponseWriter, for http.ResponseController
+func (cw *writer) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// bodyAllowed reports whether a response with status may have a body
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
@@ -0,0 +1,286 @@
+package compress_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jamra/fastrouter"
+	"github.com/jamra/fastrouter/middleware/compress"
+)
+
+var large = strings.Repeat("fastrouter compresses responses. ", 100)
+
+func textHandler(contentType, body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		io.WriteString(w, body)
+	})
+}
+
+func get(handler http.Handler, path, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	if accept != "" {
+		req.Header.Set("Accept-Encoding", accept)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func gunzip(t *testing.T, body []byte) string {
+	t.Helper()
+	r, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("Bad gzip stream: %v", err)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Bad gzip stream: %v", err)
+	}
+	return string(b)
+}
+
+func TestNegotiation(t *testing.T) {
+	handler := compress.New().Middleware(textHandler("text/plain; charset=utf-8", large))
+
+	for _, tc := range []struct {
+		accept, encoding string
+	}{
+		{"gzip", "gzip"},
+		{"deflate, gzip", "gzip"},
+		{"gzip;q=0.5, deflate", "deflate"},
+		{"*", "gzip"},
+		{"gzip;q=0, *;q=0.1", "deflate"},
+		{"br", ""},
+		{"identity", ""},
+		{"", ""},
+	} {
+		w := get(handler, "/", tc.accept)
+		if got := w.Header().Get("Content-Encoding"); got != tc.encoding {
+			t.Errorf("Accept-Encoding %q: expected %q, got %q", tc.accept, tc.encoding, got)
+		}
+		var body string
+		switch tc.encoding {
+		case "gzip":
+			body = gunzip(t, w.Body.Bytes())
+		case "deflate":
+			r, err := zlib.NewReader(w.Body)
+			if err != nil {
+				t.Fatalf("Accept-Encoding %q: bad zlib stream: %v", tc.accept, err)
+			}
+			b, _ := io.ReadAll(r)
+			body = string(b)
+		default:
+			body = w.Body.String()
+		}
+		if body != large {
+			t.Errorf("Accept-Encoding %q: body did not round-trip", tc.accept)
+		}
+	}
+
+	w := get(handler, "/", "gzip")
+	if w.Header().Get("Vary") != "Accept-Encoding" || w.Header().Get("Content-Length") != "" {
+		t.Errorf("Unexpected headers %v", w.Header())
+	}
+}
+
+func TestSkipped(t *testing.T) {
+	c := compress.New(compress.WithMinSize(100))
+	for _, tc := range []struct {
+		name    string
+		handler http.Handler
+		vary    bool
+	}{
+		{"small", textHandler("application/json", `{"ok":true}`), true},
+		{"not allowed type", textHandler("image/png", large), false},
+		{"already encoded", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/css")
+			w.Header().Set("Content-Encoding", "br")
+			io.WriteString(w, large)
+		}), false},
+		{"no content", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}), false},
+	} {
+		w := get(c.Middleware(tc.handler), "/", "gzip")
+		if got := w.Header().Get("Content-Encoding"); got == "gzip" {
+			t.Errorf("%s: expected no compression", tc.name)
+		}
+		if got := w.Header().Get("Vary") != ""; got != tc.vary {
+			t.Errorf("%s: expected Vary %v, got %v", tc.name, tc.vary, w.Header().Values("Vary"))
+		}
+	}
+
+	// Sniffed types count, and the status is kept
+	w := get(c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, "<html><body>"+large+"</body></html>")
+	})), "/", "gzip")
+	if w.Code != http.StatusCreated || w.Header().Get("Content-Encoding") != "gzip" ||
+		!strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") || w.Header().Get("ETag") != `W/"v1"` {
+		t.Errorf("Expected a compressed 201 with a sniffed type and weak ETag, got %d %v", w.Code, w.Header())
+	}
+}
+
+func TestPerRoute(t *testing.T) {
+	c := compress.New()
+	rb := fastrouter.NewRouterBuilder()
+	api := rb.Group("/api", fastrouter.WithMiddleware(c.Middleware))
+	api.AddRoute("GET", "/report", textHandler("text/csv", large))
+	rb.AddRoute("GET", "/static/*", textHandler("text/css", large))
+	router, err := rb.Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	if w := get(router, "/api/report", "gzip"); gunzip(t, w.Body.Bytes()) != large {
+		t.Error("Expected the group's routes to be compressed")
+	}
+	if w := get(router, "/static/site.css", "gzip"); w.Header().Get("Content-Encoding") != "" {
+		t.Error("Expected routes outside the group to be left alone")
+	}
+}
+
+// stubEncoder is a pluggable encoder that upper-cases its input
+type stubEncoder struct {
+	w io.Writer
+}
+
+func (e *stubEncoder) Write(b []byte) (int, error) { return e.w.Write(bytes.ToUpper(b)) }
+func (e *stubEncoder) Close() error                { return nil }
+func (e *stubEncoder) Flush() error                { return nil }
+func (e *stubEncoder) Reset(w io.Writer)           { e.w = w }
+
+func TestCustomEncoder(t *testing.T) {
+	c := compress.New(compress.WithEncoder("upper", func(w io.Writer) compress.Encoder {
+		return &stubEncoder{w: w}
+	}))
+	handler := c.Middleware(textHandler("text/plain", large))
+
+	w := get(handler, "/", "gzip, upper")
+	if w.Header().Get("Content-Encoding") != "upper" || w.Body.String() != strings.ToUpper(large) {
+		t.Errorf("Expected the custom encoding to be preferred, got %q", w.Header().Get("Content-Encoding"))
+	}
+	if w := get(handler, "/", "gzip, upper;q=0.5"); w.Header().Get("Content-Encoding") != "gzip" {
+		t.Errorf("Expected the client's preference to win, got %q", w.Header().Get("Content-Encoding"))
+	}
+
+	restricted := compress.New(compress.WithEncodings("deflate")).Middleware(textHandler("text/plain", large))
+	if w := get(restricted, "/", "gzip, deflate"); w.Header().Get("Content-Encoding") != "deflate" {
+		t.Errorf("Expected only deflate, got %q", w.Header().Get("Content-Encoding"))
+	}
+}
+
+func TestFlushAndHijack(t *testing.T) {
+	c := compress.New(compress.WithMinSize(10))
+	mux := http.NewServeMux()
+	mux.Handle("/stream", c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: "+large+"\n\n")
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("Flush failed: %v", err)
+		}
+		io.WriteString(w, "data: done\n\n")
+	})))
+	mux.Handle("/hijack", c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Errorf("Hijack failed: %v", err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+		rw.Flush()
+	})))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/stream") // the transport asks for gzip and decodes it
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !resp.Uncompressed || string(body) != "data: "+large+"\n\ndata: done\n\n" {
+		t.Errorf("Expected a flushed gzip stream, got uncompressed=%v %q", resp.Uncompressed, body)
+	}
+
+	req, _ := http.NewRequest("GET", server.URL+"/hijack", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	body, _ = io.ReadAll(bufio.NewReader(resp.Body))
+	resp.Body.Close()
+	if string(body) != "hijacked" {
+		t.Errorf("Expected the hijacked response, got %q", body)
+	}
+}
+
+func TestAllocations(t *testing.T) {
+	handler := compress.New().Middleware(textHandler("text/plain", large))
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req) // warm the pools
+
+	allocs := testing.AllocsPerRun(100, func() {
+		w.Body.Reset()
+		handler.ServeHTTP(w, req)
+	})
+	// What remains are the header values set per response
+	if allocs > 8 {
+		t.Errorf("Expected pooled writers to keep allocations low, got %v per request", allocs)
+	}
+}
+
+func TestRangeNotCompressed(t *testing.T) {
+	rb := fastrouter.NewRouterBuilder()
+	if err := rb.ServeFiles("/static/*", fstest.MapFS{"site.css": {Data: []byte(large)}}); err != nil {
+		t.Fatalf("ServeFiles failed: %v", err)
+	}
+	router, _ := rb.Build()
+	handler := compress.New().Middleware(router)
+
+	req := httptest.NewRequest("GET", "/static/site.css", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("Range", "bytes=0-99")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusPartialContent || w.Header().Get("Content-Encoding") != "" || w.Body.String() != large[:100] {
+		t.Errorf("Expected an uncompressed 206, got %d %q with %d bytes", w.Code, w.Header().Get("Content-Encoding"), w.Body.Len())
+	}
+
+	// A full response is compressed and no longer advertises ranges
+	w = get(handler, "/static/site.css", "gzip")
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Accept-Ranges") != "" {
+		t.Errorf("Expected a compressed response without Accept-Ranges, got %v", w.Header())
+	}
+}
+
+func TestPanicDropsBuffer(t *testing.T) {
+	rb := fastrouter.NewRouterBuilder()
+	rb.AddRoute("GET", "/boom", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, "partial")
+		panic("boom")
+	}), fastrouter.WithMiddleware(compress.New().Middleware))
+	router, _ := rb.Build()
+
+	w := get(router, "/boom", "gzip")
+	if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "partial") {
+		t.Errorf("Expected a clean 500, got %d %q", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,162 @@
+package fastrouter
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// mount is a router or handler attached under a path prefix
+type mount struct {
+	prefix  string // without a trailing slash, empty for the root
+	pattern string // prefix as registered, without a trailing slash
+	handler http.Handler
+}
+
+// Mount attaches h under prefix. When h is a *Router its routes are grafted
+// onto this builder's trie at Build, so matching stays a single trie walk.
+// Any other handler serves prefix and everything below it for every method,
+// extension methods included, called with prefix stripped from r.URL.Path and
+// r.URL.RawPath.
+// Mounts may be added in any order and the prefix may contain parameters.
+// Routes of the builder win over a mounted handler on the same path, while a
+// grafted route that duplicates one of them makes Build fail.
+func (rb *RouterBuilder) Mount(prefix string, h http.Handler) error {
+	if rb.built {
+		return fmt.Errorf("cannot add routes to a built router")
+	}
+	if h == nil {
+		return fmt.Errorf("mount %q: nil handler", prefix)
+	}
+
+	pattern := strings.TrimSuffix(prefix, "/")
+	prefix, err := ConvertPattern(prefix)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(prefix, "/") {
+		return fmt.Errorf("mount %q: prefix must start with '/'", prefix)
+	}
+	if strings.Contains(prefix, "*") {
+		return fmt.Errorf("mount %q: prefix must not contain a wildcard", prefix)
+	}
+
+	rb.mounts = append(rb.mounts, mount{prefix: strings.TrimSuffix(prefix, "/"), pattern: pattern, handler: h})
+	return nil
+}
+
+// routes returns the routes the mount adds to the builder
+func (m mount) routes() []Route {
+	root := m.prefix
+	if root == "" {
+		root = "/"
+	}
+
+	// A sub-router's routes move under the prefix, its "/" onto the prefix itself
+	if sub, ok := m.handler.(*Router); ok {
+		routes := make([]Route, 0, len(sub.routes))
+		for _, route := range sub.routes {
+			path, pattern := m.prefix+route.Path, m.pattern+route.Pattern
+			if route.Path == "/" {
+				path, pattern = root, m.pattern
+				if pattern == "" {
+					pattern = "/"
+				}
+			}
+			route.Path, route.Pattern = path, pattern
+			routes = append(routes, route)
+		}
+		return routes
+	}
+
+	h := &mountedHandler{
+		handler:  m.handler,
+		pattern:  m.pattern,
+		segments: strings.Count(m.prefix, "/"),
+	}
+	routes := make([]Route, 0, 2*(methodCount+1))
+	for _, method := range append(standardMethods[:], anyMethod) {
+		routes = append(routes,
+			Route{Method: method, Path: root, Handler: h, rank: impliedMethod},
+			Route{Method: method, Path: m.prefix + "/*", Handler: h, Pattern: m.pattern + "/*", rank: impliedMethod},
+		)
+	}
+	return routes
+}
+
+// mountedHandler calls a mounted http.Handler with the prefix stripped
+type mountedHandler struct {
+	handler  http.Handler
+	pattern  string // prefix as registered, for the route a router below records
+	segments int    // path segments in the prefix
+}
+
+// ServeHTTPParams strips the prefix and passes the prefix parameters, if any,
+// through the request context
+func (h *mountedHandler) ServeHTTPParams(w http.ResponseWriter, r *http.Request, params PathParams) {
+	// The params may be the caller's, so the rest, which the stripped path
+	// already carries, is dropped from a copy
+	owned := copyParams(params)
+	delete(owned, "*")
+
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.URL = new(url.URL)
+	*r2.URL = *r.URL
+	r2.URL.Path = stripSegments(r.URL.Path, h.segments)
+	if r.URL.RawPath != "" {
+		consumed := len(r.URL.Path)
+		if strings.HasSuffix(r.URL.Path, r2.URL.Path) {
+			consumed -= len(r2.URL.Path)
+		}
+		r2.URL.RawPath = stripEscaped(r.URL.RawPath, consumed)
+	}
+
+	if slot := routeSlotFrom(r.Context()); slot != nil {
+		slot.mount(h.pattern)
+	}
+	h.handler.ServeHTTP(w, WithPathParams(r2, owned))
+}
+
+// ServeHTTP strips the prefix of a request dispatched by a router other than
+// the one it is mounted on, with the params that router stored in the context
+func (h *mountedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.ServeHTTPParams(w, r, GetPathParams(r))
+}
+
+// stripSegments removes the first n segments of path, leaving at least "/"
+func stripSegments(path string, n int) string {
+	for i := 0; i < n; i++ {
+		next := strings.IndexByte(path[1:], '/')
+		if next < 0 {
+			return "/"
+		}
+		path = path[next+1:]
+	}
+	return path
+}
+
+// stripEscaped removes from rawPath the escaped form of its first n unescaped
+// bytes. The segments of the prefix are counted in the decoded path, where an
+// escaped slash is a separator, so they cannot be counted again in rawPath.
+// It returns "" when the rest does not start a path, leaving
+// url.URL.EscapedPath to escape the stripped Path instead.
+func stripEscaped(rawPath string, n int) string {
+	i := 0
+	for decoded := 0; decoded < n && i < len(rawPath); decoded++ {
+		if rawPath[i] == '%' && i+2 < len(rawPath) {
+			i += 3
+		} else {
+			i++
+		}
+	}
+	rest := rawPath[i:]
+	if rest == "" {
+		return "/"
+	}
+	if rest[0] != '/' {
+		return ""
+	}
+	return rest
+}
@@ -0,0 +1,132 @@
+package fastrouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMountRouter(t *testing.T) {
+	users := NewRouterBuilder()
+	users.AddRoute("GET", "/", textHandler("list users"))
+	users.AddRoute("GET", "/:id", textHandler("get user"))
+	usersRouter, err := users.Build()
+	if err != nil {
+		t.Fatalf("Error building sub-router: %v", err)
+	}
+
+	rb := NewRouterBuilder()
+	rb.AddRoute("GET", "/health", textHandler("health"))
+	if err := rb.Mount("/api/users", usersRouter); err != nil {
+		t.Fatalf("Error mounting router: %v", err)
+	}
+	router, err := rb.Build()
+	if err != nil {
+		t.Fatalf("Error building router: %v", err)
+	}
+
+	for _, tc := range []struct{ path, expected, id string }{
+		{"/health", "health", ""},
+		{"/api/users", "list users", ""},
+		{"/api/users/42", "get user", "42"},
+	} {
+		handler, params := router.Match("GET", tc.path)
+		if handler == nil {
+			t.Errorf("Expected a handler for %s", tc.path)
+			continue
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", tc.path, nil))
+		if w.Body.String() != tc.expected {
+			t.Errorf("Expected response '%s' for %s, got '%s'", tc.expected, tc.path, w.Body.String())
+		}
+		if params["id"] != tc.id {
+			t.Errorf("Expected id '%s' for %s, got '%s'", tc.id, tc.path, params["id"])
+		}
+		ReleaseParams(params)
+	}
+
+	// The grafted routes are part of the one trie, not a nested dispatch
+	if handler, _ := router.Match("GET", "/users/42"); handler != nil {
+		t.Error("Expected no match outside the mount prefix")
+	}
+}
+
+func TestMountHandler(t *testing.T) {
+	var gotPath, gotRawPath, gotTenant string
+	files := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotRawPath = r.URL.Path, r.URL.RawPath
+		gotTenant = GetPathParams(r)["tenant"]
+	})
+
+	rb := NewRouterBuilder()
+	rb.AddRoute("GET", "/static/special", textHandler("special"))
+	if err := rb.Mount("/static/", files); err != nil {
+		t.Fatalf("Error mounting handler: %v", err)
+	}
+	if err := rb.Mount("/t/{tenant}/files", files); err != nil {
+		t.Fatalf("Error mounting handler: %v", err)
+	}
+	router, err := rb.Build()
+	if err != nil {
+		t.Fatalf("Error building router: %v", err)
+	}
+
+	for _, tc := range []struct{ method, target, path, rawPath, tenant string }{
+		{"GET", "/static/css/site.css", "/css/site.css", "", ""},
+		{"HEAD", "/static", "/", "", ""},
+		{"GET", "/static/", "/", "", ""},
+		{"GET", "/static/a%2Fb/c", "/a/b/c", "/a%2Fb/c", ""},
+		{"POST", "/t/acme/files/report.pdf", "/report.pdf", "", "acme"},
+		{"PROPFIND", "/static/dav/notes", "/dav/notes", "", ""},
+		{"MKCOL", "/t/acme/files", "/", "", "acme"},
+		{"GET", "/t/acm%65/files/a%2Fb", "/a/b", "/a%2Fb", "acme"},
+		{"GET", "/t/acme/files%2Fx/doc", "/x/doc", "", "acme"}, // the escaped slash ends the prefix
+	} {
+		gotPath, gotRawPath, gotTenant = "", "", ""
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tc.method, tc.target, nil))
+		if gotPath != tc.path || gotRawPath != tc.rawPath || gotTenant != tc.tenant {
+			t.Errorf("%s %s: expected path '%s' raw '%s' tenant '%s', got '%s' '%s' '%s'",
+				tc.method, tc.target, tc.path, tc.rawPath, tc.tenant, gotPath, gotRawPath, gotTenant)
+		}
+	}
+
+	// Routes of the parent builder still win over the mounted subtree
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/static/special", nil))
+	if w.Body.String() != "special" {
+		t.Errorf("Expected response 'special', got '%s'", w.Body.String())
+	}
+
+	if err := NewRouterBuilder().Mount("/files/*", files); err == nil {
+		t.Error("Expected an error for a wildcard mount prefix")
+	}
+}
+
+func TestMountedHandlerKeepsCallerParams(t *testing.T) {
+	var gotParams PathParams
+	h := &mountedHandler{
+		handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotParams = GetPathParams(r)
+		}),
+		segments: 2,
+	}
+
+	// Dispatched by another router, the params come from the request context
+	req := WithPathParams(httptest.NewRequest("GET", "/t/acme/report.pdf", nil), PathParams{"tenant": "acme", "*": "report.pdf"})
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if params := GetPathParams(req); params["*"] != "report.pdf" || params["tenant"] != "acme" {
+		t.Errorf("Expected the caller's params to be left alone, got %v", params)
+	}
+	if _, ok := gotParams["*"]; ok || gotParams["tenant"] != "acme" {
+		t.Errorf("Expected the handler to get the prefix params only, got %v", gotParams)
+	}
+}
+
+// textHandler responds with body
+func textHandler(body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	})
+}
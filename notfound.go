@@ -0,0 +1,162 @@
+package fastrouter
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// WithNotFound sets the handler Router.ServeHTTP calls when no route matches
+// the path, instead of http.NotFound. The request context carries a *MissInfo
+// describing the miss, see MissInfoFromContext.
+func WithNotFound(handler http.Handler) Option {
+	return func(rb *RouterBuilder) {
+		rb.notFound = handler
+	}
+}
+
+// MissInfo records why a request matched no route, for structured 404 bodies
+// and "did you mean" hints
+type MissInfo struct {
+	Method string
+	Path   string
+
+	// Reached is the pattern of the deepest trie node the path reached, such
+	// as "/api/users/:id", or "/" when not even the first segment matched
+	Reached string
+
+	// FailedSegment is the first path segment no route accepts and
+	// FailedIndex its position among the segments. FailedIndex is -1 when
+	// every segment matched but no route ends at Reached.
+	FailedSegment string
+	FailedIndex   int
+
+	// Methods are the methods with a route at Reached
+	Methods []string
+
+	// Suggestion is the route pattern closest to Reached, at or below it, or
+	// empty if there is none
+	Suggestion string
+}
+
+var missInfoKey = &contextKey{"miss-info"}
+
+// MissInfoFromContext returns the MissInfo stored by Router.ServeHTTP before
+// calling the NotFound handler, or nil
+func MissInfoFromContext(ctx context.Context) *MissInfo {
+	info, _ := ctx.Value(missInfoKey).(*MissInfo)
+	return info
+}
+
+// serveNotFound calls the NotFound handler with the miss diagnosed, or
+// http.NotFound, which has no use for the diagnosis
+func (r *Router) serveNotFound(w http.ResponseWriter, req *http.Request) {
+	if r.notFound == nil {
+		http.NotFound(w, req)
+		return
+	}
+
+	info := r.diagnoseMiss(req.Method, req.URL.Path)
+	r.notFound.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), missInfoKey, info)))
+}
+
+// diagnoseMiss walks every trie for path, ignoring the method, and reports
+// the deepest node reached. It runs only on misses, so it favours clarity over
+// allocations.
+func (r *Router) diagnoseMiss(method, path string) *MissInfo {
+	if path == "" || path[0] != '/' {
+		path = "/" + path
+	}
+
+	// Split as Match does: "/" has no segments, a trailing slash adds an empty one
+	var segments []string
+	if path != "/" {
+		segments = strings.Split(path[1:], "/")
+	}
+
+	depth, reached := -1, ""
+	var deepest []*node // nodes at reached, one per trie that has it
+	var walk func(n *node, i int, pattern string)
+	walk = func(n *node, i int, pattern string) {
+		if i > depth {
+			depth, reached, deepest = i, pattern, nil
+		}
+		if i == depth && pattern == reached {
+			deepest = append(deepest, n)
+		}
+		if i == len(segments) {
+			return
+		}
+
+		if child, exists := n.children[segments[i]]; exists {
+			walk(child, i+1, pattern+"/"+child.segment)
+		}
+		for _, child := range n.paramChild {
+			walk(child, i+1, pattern+"/"+child.segment)
+		}
+		if n.wildChild != nil {
+			walk(n.wildChild, len(segments), pattern+"/"+n.wildChild.segment)
+		}
+	}
+	for _, root := range r.roots() {
+		walk(root, 0, "")
+	}
+
+	if reached == "" {
+		reached = "/"
+	}
+	info := &MissInfo{
+		Method:      method,
+		Path:        path,
+		Reached:     reached,
+		FailedIndex: -1,
+	}
+	if depth < len(segments) {
+		info.FailedSegment = segments[depth]
+		info.FailedIndex = depth
+	}
+
+	// The same path may end in a node of several per-method tries
+	seen := make(map[string]bool)
+	for _, n := range deepest {
+		for _, m := range n.methods() {
+			if !seen[m] {
+				seen[m] = true
+				info.Methods = append(info.Methods, m)
+			}
+		}
+		if info.Suggestion == "" {
+			info.Suggestion = closestPattern(n)
+		}
+	}
+	return info
+}
+
+// closestPattern returns the pattern of the shallowest route at or below n,
+// preferring static segments, then params, then wildcards, each in sorted order
+func closestPattern(n *node) string {
+	for level := []*node{n}; len(level) > 0; {
+		var next []*node
+		for _, n := range level {
+			if n.routeCount() > 0 {
+				return n.pattern
+			}
+
+			segments := make([]string, 0, len(n.children))
+			for segment := range n.children {
+				segments = append(segments, segment)
+			}
+			sort.Strings(segments)
+			for _, segment := range segments {
+				next = append(next, n.children[segment])
+			}
+			next = append(next, n.paramChild...)
+			if n.wildChild != nil {
+				next = append(next, n.wildChild)
+			}
+		}
+		level = next
+	}
+	return ""
+}
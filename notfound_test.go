@@ -0,0 +1,100 @@
+package fastrouter
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestNotFoundMissInfo(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithPerMethodTrees()}} {
+		var got *MissInfo
+		rb := NewRouterBuilder(append(opts, WithNotFound(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = MissInfoFromContext(r.Context())
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(got)
+		})))...)
+
+		ok := textHandler("ok")
+		rb.AddRoute("GET", "/api/users", ok)
+		rb.AddRoute("GET", "/api/users/:id", ok)
+		rb.AddRoute("PUT", "/api/users/:id", ok)
+		rb.AddRoute("GET", "/api/users/:id/posts", ok)
+		rb.AddRoute("GET", "/static/*", ok)
+		router, err := rb.Build()
+		if err != nil {
+			t.Fatalf("Error building router: %v", err)
+		}
+
+		for _, tc := range []struct {
+			path     string
+			expected MissInfo
+		}{
+			{"/api/users/42/comments", MissInfo{
+				Reached: "/api/users/:id", FailedSegment: "comments", FailedIndex: 3,
+				Methods: []string{"GET", "PUT"}, Suggestion: "/api/users/:id",
+			}},
+			{"/api/teams", MissInfo{
+				Reached: "/api", FailedSegment: "teams", FailedIndex: 1, Suggestion: "/api/users",
+			}},
+			{"/api", MissInfo{
+				Reached: "/api", FailedIndex: -1, Suggestion: "/api/users",
+			}},
+			{"/nothing", MissInfo{
+				Reached: "/", FailedSegment: "nothing", FailedIndex: 0, Suggestion: "/api/users",
+			}},
+		} {
+			got = nil
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tc.path, nil))
+			if w.Code != http.StatusNotFound || w.Header().Get("Content-Type") != "application/json" {
+				t.Errorf("%s: expected the custom JSON 404, got %d", tc.path, w.Code)
+			}
+			if got == nil {
+				t.Errorf("%s: expected MissInfo in the context", tc.path)
+				continue
+			}
+
+			tc.expected.Method, tc.expected.Path = "GET", tc.path
+			if !reflect.DeepEqual(*got, tc.expected) {
+				t.Errorf("%s: expected %+v, got %+v", tc.path, tc.expected, *got)
+			}
+		}
+	}
+}
+
+func TestNotFoundDefault(t *testing.T) {
+	rb := NewRouterBuilder()
+	rb.AddRoute("GET", "/users", textHandler("users"))
+	router, _ := rb.Build()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/missing", nil))
+	if w.Code != http.StatusNotFound || w.Body.String() != "404 page not found\n" {
+		t.Errorf("Expected the http.NotFound response, got %d '%s'", w.Code, w.Body.String())
+	}
+
+	// Method misses on a known path are still answered with 405
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/users", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", w.Code)
+	}
+}
+
+func TestNotFoundDefaultSkipsDiagnosis(t *testing.T) {
+	rb := NewRouterBuilder()
+	rb.AddRoute("GET", "/api/users/:id", textHandler("user"))
+	router, _ := rb.Build()
+
+	// Without a NotFound handler a miss costs no more than http.NotFound itself
+	req := httptest.NewRequest("GET", "/api/users/42/comments", nil)
+	w := httptest.NewRecorder()
+	direct := testing.AllocsPerRun(100, func() { http.NotFound(w, req) })
+	if allocs := testing.AllocsPerRun(100, func() { router.ServeHTTP(w, req) }); allocs > direct {
+		t.Errorf("Expected at most %.0f allocations per miss, got %.0f", direct, allocs)
+	}
+}
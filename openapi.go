@@ -0,0 +1,139 @@
+package fastrouter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// openAPIMethods lists the operation keys of an OpenAPI path item
+var openAPIMethods = map[string]string{
+	"get":     http.MethodGet,
+	"put":     http.MethodPut,
+	"post":    http.MethodPost,
+	"delete":  http.MethodDelete,
+	"options": http.MethodOptions,
+	"head":    http.MethodHead,
+	"patch":   http.MethodPatch,
+	"trace":   http.MethodTrace,
+}
+
+// openAPIDocument is the subset of an OpenAPI 3 document needed for routing
+type openAPIDocument struct {
+	OpenAPI string                                `json:"openapi"`
+	Swagger string                                `json:"swagger"`
+	Paths   map[string]map[string]json.RawMessage `json:"paths"`
+}
+
+// openAPIOperation is the subset of an OpenAPI operation object needed for routing
+type openAPIOperation struct {
+	OperationID string `json:"operationId"`
+}
+
+// FromOpenAPI creates a RouterBuilder from an OpenAPI 3.x document in JSON. YAML
+// documents and Swagger 2.0 are not supported and return an error. Every operation
+// is registered under its path, with `{param}` templates converted to `:param`
+// segments. The resolver is called with each operationId; operations it returns
+// nil for (or that have no operationId) get a handler that responds 501.
+func FromOpenAPI(spec io.Reader, resolver func(operationID string) http.Handler) (*RouterBuilder, error) {
+	var doc openAPIDocument
+	if err := json.NewDecoder(spec).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decoding OpenAPI document (only JSON is supported): %w", err)
+	}
+	if doc.Swagger != "" {
+		return nil, fmt.Errorf("unsupported Swagger %s document: only OpenAPI 3.x is supported", doc.Swagger)
+	}
+	if doc.OpenAPI == "" {
+		return nil, fmt.Errorf("not an OpenAPI document: missing 'openapi' version field")
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q: only OpenAPI 3.x is supported", doc.OpenAPI)
+	}
+
+	routes := make([]Route, 0)
+	for template, item := range doc.Paths {
+		path, err := convertOpenAPIPath(template)
+		if err != nil {
+			return nil, err
+		}
+
+		for key, raw := range item {
+			method, ok := openAPIMethods[strings.ToLower(key)]
+			if !ok {
+				continue // parameters, summary, servers, ...
+			}
+
+			var op openAPIOperation
+			if err := json.Unmarshal(raw, &op); err != nil {
+				return nil, fmt.Errorf("decoding operation %s %s: %w", method, template, err)
+			}
+
+			var handler http.Handler
+			if op.OperationID != "" && resolver != nil {
+				handler = resolver(op.OperationID)
+			}
+			if handler == nil {
+				handler = notImplementedHandler(method, template, op.OperationID)
+			}
+
+			routes = append(routes, Route{Method: method, Path: path, Handler: handler})
+		}
+	}
+
+	// AddRoute requires lexicographic order, the document's map order is random
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	rb := NewRouterBuilder()
+	for _, route := range routes {
+		if err := rb.AddRoute(route.Method, route.Path, route.Handler); err != nil {
+			return nil, err
+		}
+	}
+
+	return rb, nil
+}
+
+// convertOpenAPIPath converts an OpenAPI path template like /users/{id} into
+// the fastrouter pattern /users/:id
+func convertOpenAPIPath(template string) (string, error) {
+	if !strings.HasPrefix(template, "/") {
+		return "", fmt.Errorf("OpenAPI path %q must start with '/'", template)
+	}
+
+	segments := strings.Split(template[1:], "/")
+	for i, segment := range segments {
+		open := strings.IndexByte(segment, '{')
+		close := strings.IndexByte(segment, '}')
+		if open < 0 && close < 0 {
+			continue
+		}
+
+		if open != 0 || close != len(segment)-1 || len(segment) < 3 {
+			return "", fmt.Errorf("OpenAPI path %q: template %q must span a whole segment", template, segment)
+		}
+
+		segments[i] = ":" + segment[1:len(segment)-1]
+	}
+
+	return "/" + strings.Join(segments, "/"), nil
+}
+
+// notImplementedHandler responds 501 for operations without a resolved handler
+func notImplementedHandler(method, template, operationID string) http.Handler {
+	message := fmt.Sprintf("%s %s is not implemented", method, template)
+	if operationID != "" {
+		message = fmt.Sprintf("operation %q (%s %s) is not implemented", operationID, method, template)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, message, http.StatusNotImplemented)
+	})
+}
@@ -0,0 +1,118 @@
+package fastrouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testOpenAPISpec = `{
+  "openapi": "3.0.3",
+  "info": {"title": "Users", "version": "1.0.0"},
+  "paths": {
+    "/users": {
+      "get": {"operationId": "listUsers"},
+      "post": {"operationId": "createUser"}
+    },
+    "/users/{id}": {
+      "parameters": [{"name": "id", "in": "path", "required": true}],
+      "get": {"operationId": "getUser"},
+      "delete": {"operationId": "deleteUser"}
+    },
+    "/users/{id}/posts/{postId}": {
+      "get": {"operationId": "getUserPost"}
+    },
+    "/health": {
+      "get": {"summary": "no operationId"}
+    }
+  }
+}`
+
+func TestFromOpenAPI(t *testing.T) {
+	resolved := map[string]bool{"listUsers": true, "createUser": true, "getUser": true, "getUserPost": true}
+	resolver := func(operationID string) http.Handler {
+		if !resolved[operationID] {
+			return nil
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(operationID))
+		})
+	}
+
+	rb, err := FromOpenAPI(strings.NewReader(testOpenAPISpec), resolver)
+	if err != nil {
+		t.Fatalf("Error importing OpenAPI document: %v", err)
+	}
+
+	router, err := rb.Build()
+	if err != nil {
+		t.Fatalf("Error building router: %v", err)
+	}
+
+	testCases := []struct {
+		method         string
+		path           string
+		expectedStatus int
+		expectedBody   string
+		expectedParams map[string]string
+	}{
+		{"GET", "/users", http.StatusOK, "listUsers", map[string]string{}},
+		{"POST", "/users", http.StatusOK, "createUser", map[string]string{}},
+		{"GET", "/users/42", http.StatusOK, "getUser", map[string]string{"id": "42"}},
+		{"GET", "/users/42/posts/7", http.StatusOK, "getUserPost", map[string]string{"id": "42", "postId": "7"}},
+		{"DELETE", "/users/42", http.StatusNotImplemented, "", map[string]string{"id": "42"}},
+		{"GET", "/health", http.StatusNotImplemented, "", map[string]string{}},
+	}
+
+	for _, tc := range testCases {
+		handler, params := router.Match(tc.method, tc.path)
+		if handler == nil {
+			t.Errorf("Expected handler for %s %s, got nil", tc.method, tc.path)
+			continue
+		}
+
+		for key, expected := range tc.expectedParams {
+			if actual := params[key]; actual != expected {
+				t.Errorf("Expected param %s=%s for %s %s, got %s", key, expected, tc.method, tc.path, actual)
+			}
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+
+		if w.Code != tc.expectedStatus {
+			t.Errorf("Expected status %d for %s %s, got %d", tc.expectedStatus, tc.method, tc.path, w.Code)
+		}
+		if tc.expectedBody != "" && w.Body.String() != tc.expectedBody {
+			t.Errorf("Expected response '%s' for %s %s, got '%s'", tc.expectedBody, tc.method, tc.path, w.Body.String())
+		}
+	}
+
+	if router.RouteCount() != 6 {
+		t.Errorf("Expected 6 routes, got %d", router.RouteCount())
+	}
+}
+
+func TestFromOpenAPIErrors(t *testing.T) {
+	testCases := []struct {
+		name string
+		spec string
+		want string
+	}{
+		{"invalid JSON", `{"openapi": `, "only JSON is supported"},
+		{"YAML", "openapi: 3.0.0\npaths: {}\n", "only JSON is supported"},
+		{"missing version", `{"paths": {}}`, "missing 'openapi' version field"},
+		{"Swagger 2.0", `{"swagger": "2.0", "paths": {}}`, "only OpenAPI 3.x is supported"},
+		{"future version", `{"openapi": "4.0.0", "paths": {}}`, "only OpenAPI 3.x is supported"},
+		{"partial segment template", `{"openapi": "3.0.0", "paths": {"/files/{name}.json": {"get": {}}}}`, "{name}.json"},
+		{"relative path", `{"openapi": "3.0.0", "paths": {"users": {"get": {}}}}`, "users"},
+	}
+
+	for _, tc := range testCases {
+		_, err := FromOpenAPI(strings.NewReader(tc.spec), nil)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tc.name, tc.want, err)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package fastrouter
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ConvertPattern rewrites Go 1.22 http.ServeMux wildcards into fastrouter
+// segments: {id} becomes :id, {path...} becomes *path and a trailing {$}
+// becomes an exact trailing slash. Paths using :id and * are returned as is.
+// It is exported for tools that read route paths outside a builder, such as
+// fastrouter-gen.
+func ConvertPattern(path string) (string, error) {
+	if !strings.Contains(path, "{") && !strings.Contains(path, "}") {
+		return path, nil
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		open := strings.IndexByte(segment, '{')
+		close := strings.IndexByte(segment, '}')
+		if open < 0 && close < 0 {
+			continue
+		}
+
+		if open != 0 || close != len(segment)-1 {
+			return "", fmt.Errorf("pattern %q: wildcard %q must span a whole segment", path, segment)
+		}
+
+		isLast := i == len(segments)-1
+		name := segment[1 : len(segment)-1]
+		switch {
+		case name == "$":
+			if !isLast {
+				return "", fmt.Errorf("pattern %q: {$} must be at the end", path)
+			}
+			segments[i] = "" // exact match on the trailing slash
+
+		case strings.HasSuffix(name, "..."):
+			if !isLast {
+				return "", fmt.Errorf("pattern %q: %s must be the last segment", path, segment)
+			}
+			name = strings.TrimSuffix(name, "...")
+			if !isValidParamName(name) {
+				return "", fmt.Errorf("pattern %q: invalid wildcard name %q", path, name)
+			}
+			segments[i] = "*" + name
+
+		default:
+			if !isValidParamName(name) {
+				return "", fmt.Errorf("pattern %q: invalid wildcard name %q", path, name)
+			}
+			segments[i] = ":" + name
+		}
+	}
+
+	return strings.Join(segments, "/"), nil
+}
+
+// isValidParamName reports whether name is a Go identifier, as ServeMux requires
+func isValidParamName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9') {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// Handle registers handler for a Go 1.22 http.ServeMux style pattern such as
+// "GET /users/{id}". As with ServeMux, a pattern without a method matches every
+// method, extension methods such as PROPFIND included, unless a route for that
+// method matches too, and a pattern ending in a slash matches the whole subtree;
+// use {$} to match only the trailing slash. A GET pattern also serves HEAD,
+// unless the path has a HEAD route of its own. Registering the same method and
+// pattern twice is an error at Build. Host patterns are not supported.
+func (rb *RouterBuilder) Handle(pattern string, handler http.Handler, opts ...RouteOption) error {
+	method, path, found := strings.Cut(pattern, " ")
+	if !found {
+		method, path = "", pattern
+	}
+	path = strings.TrimLeft(path, " \t")
+
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("pattern %q: host patterns are not supported, path must start with '/'", pattern)
+	}
+
+	// A trailing slash means "this subtree" in ServeMux. The wildcard needs at
+	// least one segment, so the root pattern "/" also registers "/" itself.
+	paths := []string{path}
+	if path == "/" {
+		paths = []string{"/", "/*"}
+	} else if strings.HasSuffix(path, "/") {
+		paths = []string{path + "*"}
+	}
+
+	methods := []string{method}
+	if method == "" {
+		methods = append(standardMethods[:], anyMethod)
+	}
+
+	for _, p := range paths {
+		for _, m := range methods {
+			if err := rb.AddRoute(m, p, handler, opts...); err != nil {
+				return err
+			}
+			if method == "" {
+				rb.routes[len(rb.routes)-1].rank = impliedMethod
+			}
+		}
+
+		// As with ServeMux, GET patterns answer HEAD requests too
+		if strings.ToUpper(method) == http.MethodGet {
+			if err := rb.AddRoute(http.MethodHead, p, handler, opts...); err != nil {
+				return err
+			}
+			rb.routes[len(rb.routes)-1].rank = impliedHead
+		}
+	}
+	return nil
+}
+
+// HandleFunc registers a handler function for a ServeMux style pattern
+func (rb *RouterBuilder) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request), opts ...RouteOption) error {
+	return rb.Handle(pattern, http.HandlerFunc(handler), opts...)
+}
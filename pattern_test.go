@@ -0,0 +1,255 @@
+package fastrouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConvertPattern(t *testing.T) {
+	testCases := []struct {
+		pattern  string
+		expected string
+		wantErr  bool
+	}{
+		{"/users/:id", "/users/:id", false},
+		{"/files/*", "/files/*", false},
+		{"/users/{id}", "/users/:id", false},
+		{"/users/{id}/posts/{postId}", "/users/:id/posts/:postId", false},
+		{"/files/{path...}", "/files/*path", false},
+		{"/files/{$}", "/files/", false},
+		{"/{$}", "/", false},
+		{"/files/{path...}/raw", "", true},
+		{"/files/{$}/raw", "", true},
+		{"/files/{name}.json", "", true},
+		{"/users/{}", "", true},
+		{"/users/{1id}", "", true},
+		{"/users/{id", "", true},
+	}
+
+	for _, tc := range testCases {
+		got, err := ConvertPattern(tc.pattern)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("ConvertPattern(%q): expected error, got %q", tc.pattern, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ConvertPattern(%q): unexpected error %v", tc.pattern, err)
+			continue
+		}
+		if got != tc.expected {
+			t.Errorf("ConvertPattern(%q): expected %q, got %q", tc.pattern, tc.expected, got)
+		}
+	}
+}
+
+func TestServeMuxPatterns(t *testing.T) {
+	rb := NewRouterBuilder()
+
+	// Standard library style handlers read params with r.PathValue
+	pathValues := func(names ...string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			out := r.URL.Path + " ->"
+			for _, name := range names {
+				out += " " + name + "=" + r.PathValue(name)
+			}
+			w.Write([]byte(out))
+		}
+	}
+
+	patterns := []struct {
+		pattern string
+		handler http.HandlerFunc
+	}{
+		{"/", pathValues()},
+		{"GET /files/{$}", pathValues()},
+		{"GET /files/{path...}", pathValues("path")},
+		{"/static/", pathValues("*")},
+		{"GET /users/{id}", pathValues("id")},
+		{"DELETE /users/{id}", pathValues("id")},
+		{"GET /users/{id}/posts/{postId}", pathValues("id", "postId")},
+	}
+
+	for _, p := range patterns {
+		if err := rb.HandleFunc(p.pattern, p.handler); err != nil {
+			t.Fatalf("Error handling pattern %q: %v", p.pattern, err)
+		}
+	}
+
+	router, err := rb.Build()
+	if err != nil {
+		t.Fatalf("Error building router: %v", err)
+	}
+
+	testCases := []struct {
+		method         string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"GET", "/", http.StatusOK, "/ ->"},
+		{"POST", "/anything/else", http.StatusOK, "/anything/else ->"},
+		{"GET", "/files/", http.StatusOK, "/files/ ->"},
+		{"GET", "/files/a/b.txt", http.StatusOK, "/files/a/b.txt -> path=a/b.txt"},
+		{"PUT", "/static/css/site.css", http.StatusOK, "/static/css/site.css -> *=css/site.css"},
+		{"GET", "/users/42", http.StatusOK, "/users/42 -> id=42"},
+		{"DELETE", "/users/42", http.StatusOK, "/users/42 -> id=42"},
+		{"GET", "/users/42/posts/7", http.StatusOK, "/users/42/posts/7 -> id=42 postId=7"},
+		{"POST", "/users/42", http.StatusOK, "/users/42 ->"}, // falls back to the "/" subtree
+		{"PROPFIND", "/static/css/site.css", http.StatusOK, "/static/css/site.css -> *=css/site.css"},
+		{"PROPFIND", "/users/42", http.StatusOK, "/users/42 ->"},
+	}
+
+	for _, tc := range testCases {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+
+		if w.Code != tc.expectedStatus {
+			t.Errorf("Expected status %d for %s %s, got %d", tc.expectedStatus, tc.method, tc.path, w.Code)
+		}
+		if w.Body.String() != tc.expectedBody {
+			t.Errorf("Expected response '%s' for %s %s, got '%s'", tc.expectedBody, tc.method, tc.path, w.Body.String())
+		}
+	}
+}
+
+func TestHandleExtensionMethods(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithPerMethodTrees()}} {
+		rb := NewRouterBuilder(opts...)
+		rb.HandleFunc("/dav/", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("any")) })
+		rb.HandleFunc("PROPFIND /dav/{name}", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("propfind")) })
+		rb.HandleFunc("GET /other", func(w http.ResponseWriter, r *http.Request) {})
+		router, err := rb.Build()
+		if err != nil {
+			t.Fatalf("Error building router: %v", err)
+		}
+
+		for _, tc := range []struct{ method, path, body string }{
+			{"PROPFIND", "/dav/file", "propfind"},
+			{"PROPFIND", "/dav/a/b", "any"},
+			{"MKCOL", "/dav/new", "any"},
+			{"GET", "/dav/file", "any"},
+		} {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+			if w.Body.String() != tc.body {
+				t.Errorf("%s %s: expected %q, got %d %q", tc.method, tc.path, tc.body, w.Code, w.Body.String())
+			}
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("MKCOL", "/other", nil))
+		if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "GET, HEAD" {
+			t.Errorf("Expected 405 with Allow GET, HEAD, got %d %q", w.Code, w.Header().Get("Allow"))
+		}
+	}
+}
+
+func TestHandleGetServesHead(t *testing.T) {
+	text := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(body)) }
+	}
+	rb := NewRouterBuilder()
+	rb.HandleFunc("GET /files/{name}", text("get file"))
+	rb.HandleFunc("HEAD /files/{name}", text("head file"))
+	rb.HandleFunc("GET /users/{id}", text("get user"))
+	rb.HandleFunc("POST /users/{id}", text("post user"))
+	router, err := rb.Build()
+	if err != nil {
+		t.Fatalf("Error building router: %v", err)
+	}
+
+	for _, tc := range []struct{ method, path, body string }{
+		{"HEAD", "/users/1", "get user"},
+		{"HEAD", "/files/a", "head file"}, // an explicit HEAD route wins
+		{"GET", "/files/a", "get file"},
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		if w.Code != http.StatusOK || w.Body.String() != tc.body {
+			t.Errorf("%s %s: expected 200 %q, got %d %q", tc.method, tc.path, tc.body, w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("PUT", "/users/1", nil))
+	if allow := w.Header().Get("Allow"); allow != "GET, HEAD, POST" {
+		t.Errorf("Expected Allow GET, HEAD, POST, got %q", allow)
+	}
+}
+
+func TestHandleDuplicateRoutes(t *testing.T) {
+	text := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(body)) }
+	}
+	rb := NewRouterBuilder()
+	rb.HandleFunc("/docs/", text("any docs"))
+	rb.HandleFunc("GET /docs/", text("get docs"))
+	rb.Mount("/files", text("mounted files"))
+	rb.HandleFunc("GET /files/", text("get files"))
+	rb.HandleFunc("/items/{id}", text("any item"))
+	rb.HandleFunc("HEAD /items/{id}", text("head item"))
+	router, err := rb.Build()
+	if err != nil {
+		t.Fatalf("Error building router: %v", err)
+	}
+
+	for _, tc := range []struct{ method, path, body string }{
+		{"GET", "/docs/a", "get docs"}, // the method wins over the method-less pattern
+		{"HEAD", "/docs/a", "get docs"},
+		{"POST", "/docs/a", "any docs"},
+		{"GET", "/files/a", "get files"}, // and over a mount, whatever the order
+		{"POST", "/files/a", "mounted files"},
+		{"HEAD", "/items/1", "head item"},
+		{"GET", "/items/1", "any item"},
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		if w.Body.String() != tc.body {
+			t.Errorf("%s %s: expected %q, got %q", tc.method, tc.path, tc.body, w.Body.String())
+		}
+	}
+
+	for _, patterns := range [][]string{
+		{"GET /users/{id}", "GET /users/{id}"},
+		{"/users/", "/users/"},
+		{"HEAD /users/{id}", "HEAD /users/{id}"},
+	} {
+		rb := NewRouterBuilder()
+		for _, pattern := range patterns {
+			rb.HandleFunc(pattern, text(pattern))
+		}
+		if _, err := rb.Build(); err == nil {
+			t.Errorf("Expected an error for %q registered twice", patterns)
+		}
+	}
+}
+
+func TestHandlerFuncPathValue(t *testing.T) {
+	var fromArg, fromPathValue string
+	rb := NewRouterBuilder()
+	rb.AddRouteFunc("GET", "/users/{id}", func(w http.ResponseWriter, r *http.Request, params PathParams) {
+		fromArg, fromPathValue = params["id"], r.PathValue("id")
+	})
+	router, _ := rb.Build()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+
+	// Params reach a ParamsHandler as an argument only, keeping dispatch allocation-free
+	if fromArg != "42" || fromPathValue != "" {
+		t.Errorf("Expected id only in the params argument, got %q and PathValue %q", fromArg, fromPathValue)
+	}
+}
+
+func TestHandlePatternErrors(t *testing.T) {
+	for _, pattern := range []string{
+		"example.com/users",
+		"GET example.com/users",
+		"GET /files/{path...}/raw",
+	} {
+		if err := NewRouterBuilder().HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {}); err == nil {
+			t.Errorf("Handle(%q): expected error, got nil", pattern)
+		}
+	}
+}
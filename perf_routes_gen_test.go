@@ -0,0 +1,219 @@
+// Code generated by fastrouter-gen from perf_routes.txt. DO NOT EDIT.
+
+package fastrouter
+
+import (
+	"net/http"
+)
+
+// perfRoutes is a compiled matcher for the 12 routes in perf_routes.txt
+type perfRoutes struct{}
+
+// Match finds a handler for the given method and path with the same semantics as
+// fastrouter.Router.Match. Static routes return nil params; param and wildcard
+// routes return pooled params that can be handed back with ReleaseParams.
+func (perfRoutes) Match(method, path string) (http.Handler, PathParams) {
+	if path == "" || path[0] != '/' {
+		path = "/" + path
+	}
+	var vals [1]string
+	p0 := 1
+	if len(path) == 1 {
+		p0 = 2 // root path has no segments
+	}
+	if p0 > len(path) {
+		switch method {
+		case "GET":
+			return perfHome, nil
+		}
+	}
+	if p0 <= len(path) {
+		e0 := p0
+		for e0 < len(path) && path[e0] != '/' {
+			e0++
+		}
+		seg0 := path[p0:e0]
+		p1 := e0 + 1
+		switch len(seg0) {
+		case 3:
+			switch seg0[0] {
+			case 'a':
+				if seg0 == "api" {
+					if p1 > len(path) {
+						switch method {
+						case "GET":
+							return perfAPIHome, nil
+						}
+					}
+					if p1 <= len(path) {
+						e1 := p1
+						for e1 < len(path) && path[e1] != '/' {
+							e1++
+						}
+						seg1 := path[p1:e1]
+						p2 := e1 + 1
+						switch len(seg1) {
+						case 5:
+							switch seg1[0] {
+							case 'u':
+								if seg1 == "users" {
+									if p2 > len(path) {
+										switch method {
+										case "GET":
+											return perfAPIUsers, nil
+										case "POST":
+											return perfAPICreateUser, nil
+										}
+									}
+									if p2 <= len(path) {
+										e2 := p2
+										for e2 < len(path) && path[e2] != '/' {
+											e2++
+										}
+										seg2 := path[p2:e2]
+										p3 := e2 + 1
+										// :id
+										vals[0] = seg2
+										if p3 > len(path) {
+											switch method {
+											case "GET":
+												params := AcquireParams()
+												params["id"] = vals[0]
+												return perfAPIUser, params
+											}
+										}
+										if p3 <= len(path) {
+											e3 := p3
+											for e3 < len(path) && path[e3] != '/' {
+												e3++
+											}
+											seg3 := path[p3:e3]
+											p4 := e3 + 1
+											switch len(seg3) {
+											case 5:
+												switch seg3[0] {
+												case 'p':
+													if seg3 == "posts" {
+														if p4 > len(path) {
+															switch method {
+															case "GET":
+																params := AcquireParams()
+																params["id"] = vals[0]
+																return perfAPIPosts, params
+															}
+														}
+													}
+												}
+											}
+										}
+									}
+								}
+							}
+						}
+					}
+				}
+			}
+		case 5:
+			switch seg0[0] {
+			case 'a':
+				if seg0 == "admin" {
+					if p1 > len(path) {
+						switch method {
+						case "GET":
+							return perfAdminHome, nil
+						}
+					}
+					if p1 <= len(path) {
+						e1 := p1
+						for e1 < len(path) && path[e1] != '/' {
+							e1++
+						}
+						seg1 := path[p1:e1]
+						p2 := e1 + 1
+						switch len(seg1) {
+						case 5:
+							switch seg1[0] {
+							case 'u':
+								if seg1 == "users" {
+									if p2 > len(path) {
+										switch method {
+										case "GET":
+											return perfAdminUsers, nil
+										}
+									}
+								}
+							}
+						}
+					}
+				}
+			case 'u':
+				if seg0 == "users" {
+					if p1 > len(path) {
+						switch method {
+						case "GET":
+							return perfUsers, nil
+						}
+					}
+					if p1 <= len(path) {
+						e1 := p1
+						for e1 < len(path) && path[e1] != '/' {
+							e1++
+						}
+						seg1 := path[p1:e1]
+						p2 := e1 + 1
+						// :id
+						vals[0] = seg1
+						if p2 > len(path) {
+							switch method {
+							case "GET":
+								params := AcquireParams()
+								params["id"] = vals[0]
+								return perfUser, params
+							}
+						}
+						if p2 <= len(path) {
+							e2 := p2
+							for e2 < len(path) && path[e2] != '/' {
+								e2++
+							}
+							seg2 := path[p2:e2]
+							p3 := e2 + 1
+							switch len(seg2) {
+							case 8:
+								switch seg2[0] {
+								case 's':
+									if seg2 == "settings" {
+										if p3 > len(path) {
+											switch method {
+											case "GET":
+												params := AcquireParams()
+												params["id"] = vals[0]
+												return perfUserSettings, params
+											}
+										}
+									}
+								}
+							}
+						}
+					}
+				}
+			}
+		case 6:
+			switch seg0[0] {
+			case 's':
+				if seg0 == "static" {
+					if p1 <= len(path) {
+						// *
+						switch method {
+						case "GET":
+							params := AcquireParams()
+							params["*"] = path[p1:]
+							return perfStatic, params
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"context"
+	"hash/maphash"
+	"sync"
+	"time"
+)
+
+// shardCount is the number of independently locked shards of a MemoryStore
+const shardCount = 64
+
+// sweepEvery is the number of takes on a shard between sweeps of its
+// expired keys
+const sweepEvery = 1024
+
+// MemoryStore keeps limiter state in process, sharded so concurrent requests
+// for different keys rarely contend. Keys whose limit has fully recovered are
+// dropped over time.
+type MemoryStore struct {
+	seed   maphash.Seed
+	shards [shardCount]shard
+}
+
+// shard holds the theoretical arrival time of its keys
+type shard struct {
+	mu    sync.Mutex
+	tats  map[string]time.Time
+	takes int
+}
+
+// NewMemoryStore returns an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	s := &MemoryStore{seed: maphash.MakeSeed()}
+	for i := range s.shards {
+		s.shards[i].tats = make(map[string]time.Time)
+	}
+	return s
+}
+
+// Take implements Store
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	sh := &s.shards[maphash.String(s.seed, key)%shardCount]
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	result, tat := take(sh.tats[key], limit, now)
+	if result.Allowed {
+		sh.tats[key] = tat
+	}
+
+	if sh.takes++; sh.takes >= sweepEvery {
+		sh.takes = 0
+		for k, t := range sh.tats {
+			if !t.After(now) {
+				delete(sh.tats, k)
+			}
+		}
+	}
+	return result, nil
+}
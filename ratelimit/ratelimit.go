@@ -0,0 +1,195 @@
+// Package ratelimit limits the request rate of routes, groups or whole
+// routers, using the generic cell rate algorithm (GCRA), a token bucket that
+// keeps a single timestamp per key.
+//
+//	limiter := ratelimit.New(ratelimit.Limit{Rate: 100, Period: time.Minute},
+//		ratelimit.WithKey(ratelimit.ByParam("tenantId")))
+//	rb.AddRoute("GET", "/tenants/:tenantId/reports", reports,
+//		fastrouter.WithMiddleware(limiter.Middleware))
+//
+// Requests over the limit get a 429 Too Many Requests with a Retry-After
+// header.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jamra/fastrouter"
+)
+
+// Limit is a request rate: Rate requests per Period, of which up to Burst
+// may arrive at once
+type Limit struct {
+	Rate   int
+	Period time.Duration
+	Burst  int // Rate if zero
+}
+
+// interval returns the time one request uses up of the limit
+func (l Limit) interval() time.Duration {
+	return l.Period / time.Duration(l.Rate)
+}
+
+// burst returns the number of requests allowed at once
+func (l Limit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Rate
+}
+
+// Result is the outcome of taking a request from a key's limit
+type Result struct {
+	Allowed    bool
+	Remaining  int           // requests still allowed at once
+	RetryAfter time.Duration // until the next request is allowed, zero if Allowed
+}
+
+// Store keeps the state of every key. MemoryStore keeps it in process; a
+// shared store such as Redis can implement Take as a script over one
+// timestamp per key, as GCRA needs no more.
+type Store interface {
+	// Take counts one request for key against limit at now
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+// KeyFunc returns the key a request is limited by. Requests with the same key
+// share a limit; an empty key is a key like any other.
+type KeyFunc func(r *http.Request) string
+
+// ByIP keys requests by the host of their remote address. Behind a proxy,
+// use a KeyFunc that reads the header the proxy sets instead.
+func ByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ByHeader keys requests by the value of a header, such as an API key
+func ByHeader(name string) KeyFunc {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// ByParam keys requests by a path param of the matched route, such as a
+// tenant ID
+func ByParam(name string) KeyFunc {
+	return func(r *http.Request) string {
+		return fastrouter.GetPathParams(r)[name]
+	}
+}
+
+// Limiter enforces a Limit per key
+type Limiter struct {
+	limit Limit
+	key   KeyFunc
+	store Store
+	now   func() time.Time
+}
+
+// Option configures a Limiter
+type Option func(*Limiter)
+
+// WithKey sets how requests are keyed, ByIP by default
+func WithKey(key KeyFunc) Option {
+	return func(l *Limiter) {
+		l.key = key
+	}
+}
+
+// WithStore sets the store of the limiter's state, a new MemoryStore by
+// default. Limiters sharing a store need distinct keys.
+func WithStore(store Store) Option {
+	return func(l *Limiter) {
+		l.store = store
+	}
+}
+
+// WithClock sets the limiter's clock, time.Now by default, for tests
+func WithClock(now func() time.Time) Option {
+	return func(l *Limiter) {
+		l.now = now
+	}
+}
+
+// New returns a Limiter enforcing limit. It panics if the limit's Rate or
+// Period is not positive, or if Period is too short to divide into Rate
+// intervals of at least a nanosecond.
+func New(limit Limit, opts ...Option) *Limiter {
+	if limit.Rate <= 0 || limit.Period <= 0 {
+		panic("ratelimit: Rate and Period must be positive")
+	}
+	if limit.interval() <= 0 {
+		panic(fmt.Sprintf("ratelimit: Period %v is shorter than Rate %d nanoseconds", limit.Period, limit.Rate))
+	}
+	l := &Limiter{limit: limit, key: ByIP, now: time.Now}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if l.store == nil {
+		l.store = NewMemoryStore()
+	}
+	return l
+}
+
+// Allow takes one request from the limit of r's key
+func (l *Limiter) Allow(r *http.Request) (Result, error) {
+	return l.store.Take(r.Context(), l.key(r), l.limit, l.now())
+}
+
+// Middleware returns next limited by l, answering 429 Too Many Requests with a
+// Retry-After header to requests over the limit. Requests are let through
+// when the store fails, so an outage of a shared store does not take the
+// routes down; the error is logged through slog.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result, err := l.Allow(r)
+		if err != nil {
+			slog.WarnContext(r.Context(), "ratelimit: store failed, allowing request", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !result.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(result.RetryAfter)))
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// retryAfterSeconds rounds d up to whole seconds, as Retry-After takes
+func retryAfterSeconds(d time.Duration) int {
+	seconds := int((d + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
+
+// take applies GCRA to a key whose theoretical arrival time is tat, returning
+// the result and the key's new theoretical arrival time
+func take(tat time.Time, limit Limit, now time.Time) (Result, time.Time) {
+	interval := limit.interval()
+	window := interval * time.Duration(limit.burst())
+	if tat.Before(now) {
+		tat = now
+	}
+
+	next := tat.Add(interval)
+	allowAt := next.Add(-window)
+	if now.Before(allowAt) {
+		return Result{RetryAfter: allowAt.Sub(now)}, tat
+	}
+	remaining := int(now.Sub(allowAt) / interval)
+	return Result{Allowed: true, Remaining: remaining}, next
+}
@@ -0,0 +1,160 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jamra/fastrouter"
+	"github.com/jamra/fastrouter/ratelimit"
+)
+
+// clock is a manually advanced clock
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time { return c.now }
+
+func (c *clock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func TestLimiter(t *testing.T) {
+	c := &clock{now: time.Unix(1700000000, 0)}
+	limiter := ratelimit.New(ratelimit.Limit{Rate: 2, Period: time.Second}, ratelimit.WithClock(c.Now))
+	req := httptest.NewRequest("GET", "/", nil)
+
+	for i, want := range []struct {
+		allowed   bool
+		remaining int
+	}{{true, 1}, {true, 0}, {false, 0}} {
+		result, err := limiter.Allow(req)
+		if err != nil {
+			t.Fatalf("Allow failed: %v", err)
+		}
+		if result.Allowed != want.allowed || result.Remaining != want.remaining {
+			t.Errorf("request %d: expected allowed=%v remaining=%d, got %+v", i, want.allowed, want.remaining, result)
+		}
+		if !result.Allowed && result.RetryAfter != 500*time.Millisecond {
+			t.Errorf("request %d: expected to retry after 500ms, got %v", i, result.RetryAfter)
+		}
+	}
+
+	c.Advance(500 * time.Millisecond)
+	if result, _ := limiter.Allow(req); !result.Allowed {
+		t.Errorf("Expected a request to be allowed once a token is back, got %+v", result)
+	}
+	if result, _ := limiter.Allow(req); result.Allowed {
+		t.Errorf("Expected the limit to be used up again, got %+v", result)
+	}
+
+	// Other clients have their own limit
+	other := httptest.NewRequest("GET", "/", nil)
+	other.RemoteAddr = "198.51.100.7:4000"
+	if result, _ := limiter.Allow(other); !result.Allowed {
+		t.Errorf("Expected another IP to be allowed, got %+v", result)
+	}
+}
+
+func TestBurst(t *testing.T) {
+	c := &clock{now: time.Unix(1700000000, 0)}
+	limiter := ratelimit.New(ratelimit.Limit{Rate: 60, Period: time.Minute, Burst: 3}, ratelimit.WithClock(c.Now))
+	req := httptest.NewRequest("GET", "/", nil)
+
+	allowed := 0
+	for i := 0; i < 10; i++ {
+		if result, _ := limiter.Allow(req); result.Allowed {
+			allowed++
+		}
+	}
+	if allowed != 3 {
+		t.Errorf("Expected a burst of 3, got %d", allowed)
+	}
+}
+
+func TestMiddlewarePerParam(t *testing.T) {
+	c := &clock{now: time.Unix(1700000000, 0)}
+	limiter := ratelimit.New(ratelimit.Limit{Rate: 1, Period: 10 * time.Second},
+		ratelimit.WithClock(c.Now), ratelimit.WithKey(ratelimit.ByParam("tenantId")))
+
+	rb := fastrouter.NewRouterBuilder()
+	rb.AddRouteFunc("GET", "/health", func(w http.ResponseWriter, r *http.Request, params fastrouter.PathParams) {})
+	tenants := rb.Group("/tenants/:tenantId", fastrouter.WithMiddleware(limiter.Middleware))
+	tenants.AddRouteFunc("GET", "/reports", func(w http.ResponseWriter, r *http.Request, params fastrouter.PathParams) {
+		w.Write([]byte(params["tenantId"]))
+	})
+	router, err := rb.Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	if w := serve("/tenants/a/reports"); w.Code != http.StatusOK || w.Body.String() != "a" {
+		t.Errorf("Expected the first request through, got %d %q", w.Code, w.Body.String())
+	}
+	w := serve("/tenants/a/reports")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "10" {
+		t.Errorf("Expected 429 with Retry-After 10, got %d %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := serve("/tenants/b/reports"); w.Code != http.StatusOK {
+		t.Errorf("Expected another tenant through, got %d", w.Code)
+	}
+	for i := 0; i < 3; i++ {
+		if w := serve("/health"); w.Code != http.StatusOK {
+			t.Errorf("Expected routes outside the group to be unlimited, got %d", w.Code)
+		}
+	}
+
+	c.Advance(10 * time.Second)
+	if w := serve("/tenants/a/reports"); w.Code != http.StatusOK {
+		t.Errorf("Expected the tenant through after the period, got %d", w.Code)
+	}
+}
+
+// failingStore is a Store that is down
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, limit ratelimit.Limit, now time.Time) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("connection refused")
+}
+
+func TestStoreFailureAllows(t *testing.T) {
+	limiter := ratelimit.New(ratelimit.Limit{Rate: 1, Period: time.Hour}, ratelimit.WithStore(failingStore{}),
+		ratelimit.WithKey(ratelimit.ByHeader("X-API-Key")))
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected requests through while the store fails, got %d", w.Code)
+		}
+	}
+}
+
+func TestNewRejectsInvalidLimits(t *testing.T) {
+	for _, limit := range []ratelimit.Limit{
+		{Rate: 0, Period: time.Second},
+		{Rate: -1, Period: time.Second},
+		{Rate: 10, Period: 0},
+		{Rate: 1e9, Period: time.Millisecond}, // Period/Rate truncates to 0
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected New to panic on %+v", limit)
+				}
+			}()
+			ratelimit.New(limit)
+		}()
+	}
+
+	// One request per nanosecond is the finest rate there is
+	ratelimit.New(ratelimit.Limit{Rate: 1e6, Period: time.Millisecond})
+}
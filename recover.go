@@ -0,0 +1,48 @@
+package fastrouter
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/jamra/fastrouter/internal/respwriter"
+)
+
+// WithPanicHandler sets the function called when a handler dispatched by
+// Router.ServeHTTP panics, with the value passed to panic. The default logs
+// the panic, route pattern, request ID and stack through slog and answers 500, or aborts
+// the connection if the response had already started. Once the response has
+// started, a status written by the panic handler is dropped.
+func WithPanicHandler(handler func(w http.ResponseWriter, r *http.Request, recovered any)) Option {
+	return func(rb *RouterBuilder) {
+		rb.panicHandler = handler
+	}
+}
+
+// recoverPanic handles a panic raised while serving req through route.
+// http.ErrAbortHandler is re-raised, as net/http expects.
+func (r *Router) recoverPanic(rw *respwriter.Writer, req *http.Request, route *RouteInfo, recovered any) {
+	if recovered == http.ErrAbortHandler {
+		panic(recovered)
+	}
+
+	if r.panicHandler != nil {
+		r.panicHandler(rw, req, recovered)
+		return
+	}
+
+	slog.ErrorContext(req.Context(), "fastrouter: panic serving request",
+		"method", req.Method,
+		"path", req.URL.Path,
+		"route", route.Pattern,
+		"request_id", GetRequestID(req),
+		"panic", recovered,
+		"stack", string(debug.Stack()),
+	)
+
+	// A truncated response must not look complete to the client
+	if rw.Written() {
+		panic(http.ErrAbortHandler)
+	}
+	http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
@@ -0,0 +1,112 @@
+package fastrouter
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func buildPanicRouter(t *testing.T, opts ...Option) *Router {
+	t.Helper()
+	rb := NewRouterBuilder(opts...)
+	rb.AddRoute("GET", "/abort", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	rb.AddRoute("GET", "/partial", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		panic("after write")
+	}))
+	rb.AddRouteFunc("GET", "/users/:id", func(w http.ResponseWriter, r *http.Request, params PathParams) {
+		panic("user " + params["id"])
+	})
+	router, err := rb.Build()
+	if err != nil {
+		t.Fatalf("Error building router: %v", err)
+	}
+	return router
+}
+
+// serveRecovering serves req and returns the value ServeHTTP panicked with
+func serveRecovering(router *Router, w http.ResponseWriter, req *http.Request) (recovered any) {
+	defer func() {
+		recovered = recover()
+	}()
+	router.ServeHTTP(w, req)
+	return nil
+}
+
+func TestPanicDefaultHandler(t *testing.T) {
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	router := buildPanicRouter(t)
+
+	w := httptest.NewRecorder()
+	if p := serveRecovering(router, w, httptest.NewRequest("GET", "/users/42", nil)); p != nil {
+		t.Fatalf("Expected the panic to be recovered, got %v", p)
+	}
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", w.Code)
+	}
+	for _, want := range []string{"panic serving request", "route=/users/:id", `panic="user 42"`, "stack="} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("Expected log to contain %q, got %s", want, logs.String())
+		}
+	}
+
+	// A response that has started is aborted rather than completed with a 500
+	w = httptest.NewRecorder()
+	if p := serveRecovering(router, w, httptest.NewRequest("GET", "/partial", nil)); p != http.ErrAbortHandler {
+		t.Errorf("Expected http.ErrAbortHandler after a partial write, got %v", p)
+	}
+	if w.Code != http.StatusOK || w.Body.String() != "partial" {
+		t.Errorf("Expected the partial response to be left untouched, got %d '%s'", w.Code, w.Body.String())
+	}
+
+	// http.ErrAbortHandler is for net/http and passes through
+	if p := serveRecovering(router, httptest.NewRecorder(), httptest.NewRequest("GET", "/abort", nil)); p != http.ErrAbortHandler {
+		t.Errorf("Expected http.ErrAbortHandler to be re-raised, got %v", p)
+	}
+}
+
+func TestPanicCustomHandler(t *testing.T) {
+	var got any
+	router := buildPanicRouter(t, WithPanicHandler(func(w http.ResponseWriter, r *http.Request, recovered any) {
+		got = recovered
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/users/7", nil))
+	if got != "user 7" || w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected custom handler to get 'user 7' and answer 503, got %v with %d", got, w.Code)
+	}
+
+	// The status of a started response is not written twice
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/partial", nil))
+	if got != "after write" || w.Code != http.StatusOK {
+		t.Errorf("Expected custom handler to keep the started 200, got %v with %d", got, w.Code)
+	}
+}
+
+func TestResponseWriterPassthrough(t *testing.T) {
+	rb := NewRouterBuilder()
+	rb.AddRoute("GET", "/stream", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("chunk"))
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("Expected Flush to reach the underlying writer, got %v", err)
+		}
+	}))
+	router, _ := rb.Build()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/stream", nil))
+	if !w.Flushed || w.Body.String() != "chunk" {
+		t.Errorf("Expected a flushed 'chunk' response, got flushed=%v '%s'", w.Flushed, w.Body.String())
+	}
+}
@@ -0,0 +1,84 @@
+package fastrouter
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"net/http"
+	"time"
+)
+
+// RequestIDHeader is the header a request ID is read from and echoed in
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the length of a client supplied request ID
+const maxRequestIDLength = 128
+
+var requestIDKey = &contextKey{"request-id"}
+
+// RequestID is middleware that gives every request an ID. It keeps a valid
+// X-Request-ID sent by the client and otherwise generates a sortable one. The
+// ID is stored in the request context, where GetRequestID and the default
+// panic report read it, and echoed in the X-Request-ID response header.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = NewRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey, id)))
+	})
+}
+
+// GetRequestID returns the ID RequestID gave r, or "" if it went through no
+// RequestID middleware
+func GetRequestID(r *http.Request) string {
+	return RequestIDFromContext(r.Context())
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, or ""
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// crockford is the Crockford base32 alphabet, which sorts like the numbers
+// it encodes
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewRequestID returns a new 26 character ID in the ULID layout: a 48-bit
+// millisecond timestamp followed by 80 random bits, Crockford base32 encoded.
+// IDs sort by creation time to the millisecond.
+func NewRequestID() string {
+	var b [16]byte
+	ms := uint64(time.Now().UnixMilli())
+	binary.BigEndian.PutUint16(b[0:], uint16(ms>>32))
+	binary.BigEndian.PutUint32(b[2:], uint32(ms))
+	rand.Read(b[6:])
+
+	// 128 bits in 26 characters of 5 bits, the first holding the top 3 bits
+	hi := binary.BigEndian.Uint64(b[:8])
+	lo := binary.BigEndian.Uint64(b[8:])
+	var id [26]byte
+	for i := 25; i >= 0; i-- {
+		id[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(id[:])
+}
+
+// validRequestID reports whether a client supplied ID is safe to keep: not
+// empty, not too long and only visible ASCII, so it cannot forge log lines
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
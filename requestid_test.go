@@ -0,0 +1,78 @@
+package fastrouter
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	rb := NewRouterBuilder()
+	rb.AddRoute("GET", "/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = GetRequestID(r)
+	}))
+	router, _ := rb.Build()
+	handler := RequestID(router)
+
+	// A valid client ID is kept and echoed
+	req := httptest.NewRequest("GET", "/users/42", nil)
+	req.Header.Set(RequestIDHeader, "client-id-1")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if seen != "client-id-1" || w.Header().Get(RequestIDHeader) != "client-id-1" {
+		t.Errorf("Expected the client ID, got %q and header %q", seen, w.Header().Get(RequestIDHeader))
+	}
+
+	// Missing or unsafe IDs are replaced
+	for _, id := range []string{"", "has space", "line\nbreak", strings.Repeat("x", maxRequestIDLength+1)} {
+		req := httptest.NewRequest("GET", "/users/42", nil)
+		req.Header.Set(RequestIDHeader, id)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if seen == id || len(seen) != 26 || w.Header().Get(RequestIDHeader) != seen {
+			t.Errorf("Expected a generated ID for %q, got %q and header %q", id, seen, w.Header().Get(RequestIDHeader))
+		}
+	}
+
+	if id := GetRequestID(httptest.NewRequest("GET", "/", nil)); id != "" {
+		t.Errorf("Expected no ID outside the middleware, got %q", id)
+	}
+}
+
+func TestNewRequestIDSortable(t *testing.T) {
+	var ids []string
+	for i := 0; i < 3; i++ {
+		ids = append(ids, NewRequestID())
+		time.Sleep(2 * time.Millisecond)
+	}
+	if !sort.StringsAreSorted(ids) {
+		t.Errorf("Expected IDs sorted by time, got %v", ids)
+	}
+	for _, id := range ids {
+		if len(id) != 26 || strings.Trim(id, crockford) != "" {
+			t.Errorf("Expected a 26 character base32 ID, got %q", id)
+		}
+	}
+	if NewRequestID() == NewRequestID() {
+		t.Error("Expected distinct IDs")
+	}
+}
+
+func TestRequestIDInPanicReport(t *testing.T) {
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	req := httptest.NewRequest("GET", "/users/42", nil)
+	req.Header.Set(RequestIDHeader, "req-42")
+	RequestID(buildPanicRouter(t)).ServeHTTP(httptest.NewRecorder(), req)
+	if !strings.Contains(logs.String(), "request_id=req-42") {
+		t.Errorf("Expected the request ID in the panic report, got %s", logs.String())
+	}
+}
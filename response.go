@@ -0,0 +1,30 @@
+package fastrouter
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/jamra/fastrouter/internal/respwriter"
+)
+
+// responseWriterPool holds the writers Router.ServeHTTP wraps responses in,
+// so a recovered panic is not answered over a response that has already
+// started. A pooled writer is only valid until the handler returns.
+var responseWriterPool = sync.Pool{
+	New: func() interface{} {
+		return &respwriter.Writer{}
+	},
+}
+
+// acquireResponseWriter wraps w in a pooled respwriter.Writer
+func acquireResponseWriter(w http.ResponseWriter) *respwriter.Writer {
+	rw := responseWriterPool.Get().(*respwriter.Writer)
+	rw.Reset(w)
+	return rw
+}
+
+// releaseResponseWriter returns rw to the pool
+func releaseResponseWriter(rw *respwriter.Writer) {
+	rw.Reset(nil)
+	responseWriterPool.Put(rw)
+}
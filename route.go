@@ -0,0 +1,232 @@
+package fastrouter
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// RouteInfo describes a registered route. Each route has one RouteInfo,
+// created at Build, so it can be handed out without allocating.
+type RouteInfo struct {
+	Method  string
+	Pattern string // path template as registered, such as "/api/users/:id"
+	Name    string // set with WithName, or empty
+
+	depth  int         // number of path segments, the depth of the node in the trie
+	values map[any]any // set with WithRouteValue
+}
+
+// Value returns the value set for key on the route with WithRouteValue, or nil
+func (ri *RouteInfo) Value(key any) any {
+	return ri.values[key]
+}
+
+// RouteOption configures a single route
+type RouteOption func(*Route)
+
+// WithName names a route, for logs, metrics and traces
+func WithName(name string) RouteOption {
+	return func(route *Route) {
+		route.Name = name
+	}
+}
+
+// WithRouteValue attaches a value to a route, read back with RouteInfo.Value
+// by middleware that looks the route up, such as a CORS policy. Keys compare
+// like context keys and should be of an unexported type.
+func WithRouteValue(key, value any) RouteOption {
+	return func(route *Route) {
+		values := make(map[any]any, len(route.Values)+1)
+		for k, v := range route.Values {
+			values[k] = v
+		}
+		values[key] = value
+		route.Values = values
+	}
+}
+
+// WithMiddleware wraps a route's handler in middleware, the first outermost.
+// On a Group it wraps every route of the group, outside the routes' own.
+// Params reach the middleware through GetPathParams.
+func WithMiddleware(middleware ...func(http.Handler) http.Handler) RouteOption {
+	return func(route *Route) {
+		route.Middleware = append(route.Middleware[:len(route.Middleware):len(route.Middleware)], middleware...)
+	}
+}
+
+// compile returns the handler stored on the trie for route, wrapped by its
+// per-route options
+func (route Route) compile() http.Handler {
+	handler := route.Handler
+	if route.Timeout > 0 {
+		handler = &timeoutHandler{handler: handler, timeout: route.Timeout}
+	}
+	if route.MaxBodyBytes > 0 || len(route.Consumes) > 0 {
+		handler = &bodyHandler{handler: handler, maxBytes: route.MaxBodyBytes, consumes: route.Consumes}
+	}
+	for i := len(route.Middleware) - 1; i >= 0; i-- {
+		handler = route.Middleware[i](handler)
+	}
+	return handler
+}
+
+// setRoute registers route on n, whose normalized path is path
+func (n *node) setRoute(route Route, path string) {
+	n.setHandler(route.Method, route.Handler)
+	n.pattern = path
+
+	pattern := route.Pattern
+	if pattern == "" {
+		pattern = path
+	}
+	n.setRouteInfo(route.Method, &RouteInfo{Method: route.Method, Pattern: pattern, Name: route.Name, depth: pathDepth(path), values: route.Values})
+}
+
+// setRouteInfo stores the route of a method's handler, allocating the node's
+// route table on first use
+func (n *node) setRouteInfo(method string, info *RouteInfo) {
+	if n.routes == nil {
+		n.routes = &nodeRoutes{}
+	}
+	if index := methodIndex(method); index != noMethodIndex {
+		n.routes.standard[index] = info
+		return
+	}
+	if n.routes.extra == nil {
+		n.routes.extra = make(map[string]*RouteInfo)
+	}
+	n.routes.extra[method] = info
+}
+
+// pathDepth returns the number of segments of a normalized path, 0 for "/"
+func pathDepth(path string) int {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return 0
+	}
+	return strings.Count(path, "/") + 1
+}
+
+// route returns the route registered on n for a resolved method
+func (n *node) route(index int, method string) *RouteInfo {
+	if n.routes == nil {
+		return nil
+	}
+	if index != noMethodIndex {
+		return n.routes.standard[index]
+	}
+	return n.routes.extra[method]
+}
+
+// routeSlot receives the route matched by Router.ServeHTTP. Installing it
+// before routing lets middleware read the route after the handler returns.
+type routeSlot struct {
+	route  *RouteInfo
+	params PathParams // copied from the router's pooled map, reused across records
+
+	// A handler attached with Mount sets prefix to the full pattern it is
+	// mounted at, so that a router it calls records its route below it
+	prefix  string
+	mounted bool
+}
+
+// record stores the matched route and a copy of its params. Below a mount the
+// route is rebuilt with the mount's pattern in front, and the params are
+// added to those of the mount, whose rest of the path the route now matches.
+func (s *routeSlot) record(route *RouteInfo, params PathParams) {
+	if s.mounted {
+		s.mounted = false
+		pattern := s.prefix + route.Pattern
+		if route.Pattern == "/" && s.prefix != "" {
+			pattern = s.prefix
+		}
+		full := *route
+		full.Pattern = pattern
+		full.depth += pathDepth(s.prefix)
+		route = &full
+		delete(s.params, "*")
+	} else {
+		s.prefix = ""
+		clear(s.params)
+	}
+
+	s.route = route
+	if len(params) == 0 {
+		return
+	}
+	if s.params == nil {
+		s.params = make(PathParams, len(params))
+	}
+	for k, v := range params {
+		s.params[k] = v
+	}
+}
+
+// mount notes that a handler mounted at pattern below the recorded route is
+// serving the request
+func (s *routeSlot) mount(pattern string) {
+	s.prefix += pattern
+	s.mounted = true
+}
+
+var routeKey = &contextKey{"route"}
+
+func routeSlotFrom(ctx context.Context) *routeSlot {
+	slot, _ := ctx.Value(routeKey).(*routeSlot)
+	return slot
+}
+
+// RecordRoute returns a shallow copy of r whose context records the route a
+// Router further down the chain matches, for middleware such as loggers and
+// metrics. After the router has served the request, RouteFromContext on the
+// returned request's context reports the route. r is returned as is if it
+// already records routes.
+//
+// When a handler attached with Mount calls another Router, the route recorded
+// is the inner router's with the mount's pattern in front, as if the inner
+// router had been mounted directly, and the params of both are recorded.
+// Routers nested any other way, such as behind http.StripPrefix, each record
+// their own route and the innermost one wins.
+func RecordRoute(r *http.Request) *http.Request {
+	if routeSlotFrom(r.Context()) != nil {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), routeKey, &routeSlot{}))
+}
+
+// RouteFromContext returns the route matched by Router.ServeHTTP, or nil if
+// none matched yet. The route is recorded when the request went through
+// RecordRoute or the router was built WithRouteContext; otherwise ServeHTTP
+// leaves the context alone so dispatch stays allocation-free.
+func RouteFromContext(ctx context.Context) *RouteInfo {
+	if slot := routeSlotFrom(ctx); slot != nil {
+		return slot.route
+	}
+	return nil
+}
+
+// Unmatched stands in for the route pattern of requests no route matched, in
+// access logs and metric labels
+const Unmatched = "<unmatched>"
+
+// RouteParamsFromContext returns the path params of the route RouteFromContext
+// reports, or nil if it has none. The router releases its own params when the
+// handler returns, so middleware that wraps the router reads them here rather
+// than through GetPathParams. They are recorded only for requests that went
+// through RecordRoute.
+func RouteParamsFromContext(ctx context.Context) PathParams {
+	if slot := routeSlotFrom(ctx); slot != nil && len(slot.params) > 0 {
+		return slot.params
+	}
+	return nil
+}
+
+// WithRouteContext makes Router.ServeHTTP record the matched route in the
+// context of every request, so handlers can call RouteFromContext without a
+// RecordRoute middleware. This costs two allocations per request.
+func WithRouteContext() Option {
+	return func(rb *RouterBuilder) {
+		rb.routeContext = true
+	}
+}
@@ -0,0 +1,207 @@
+package fastrouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMatchRoute(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithPerMethodTrees()}} {
+		rb := NewRouterBuilder(opts...)
+		rb.AddRoute("GET", "/api/health", textHandler("health"), WithName("health"))
+		rb.AddRoute("GET", "/api/users/{id}", textHandler("user"), WithName("user"))
+		rb.AddRoute("PROPFIND", "/api/users/{id}", textHandler("props"))
+		rb.AddRoute("GET", "/files/*path", textHandler("files"))
+		router, err := rb.Build()
+		if err != nil {
+			t.Fatalf("Error building router: %v", err)
+		}
+
+		for _, tc := range []struct{ method, path, pattern, name string }{
+			{"GET", "/api/health", "/api/health", "health"},
+			{"GET", "/api/users/42", "/api/users/{id}", "user"},
+			{"PROPFIND", "/api/users/42", "/api/users/{id}", ""},
+			{"GET", "/files/a/b", "/files/*path", ""},
+		} {
+			handler, params, route := router.MatchRoute(tc.method, tc.path)
+			if handler == nil || route == nil {
+				t.Errorf("%s %s: expected a match", tc.method, tc.path)
+				continue
+			}
+			if route.Method != tc.method || route.Pattern != tc.pattern || route.Name != tc.name {
+				t.Errorf("%s %s: expected route %s %s %q, got %+v", tc.method, tc.path, tc.method, tc.pattern, tc.name, *route)
+			}
+			ReleaseParams(params)
+		}
+
+		if handler, _, route := router.MatchRoute("DELETE", "/api/health"); handler != nil || route != nil {
+			t.Errorf("Expected no route for an unregistered method, got %+v", route)
+		}
+	}
+}
+
+func TestRouteInfoOnlyOnRouteNodes(t *testing.T) {
+	rb := NewRouterBuilder()
+	rb.AddRoute("GET", "/api/users/:id", textHandler("user"))
+	rb.AddRoute("PROPFIND", "/api/users/:id", textHandler("props"))
+	router, _ := rb.Build()
+
+	api := router.root.children["api"]
+	users := api.children["users"]
+	if api.routes != nil || users.routes != nil {
+		t.Error("Expected nodes without handlers to have no route table")
+	}
+	user := users.paramChild[0]
+	if user.routes == nil || user.route(methodIndex("GET"), "GET").Pattern != "/api/users/:id" ||
+		user.route(noMethodIndex, "PROPFIND") == nil {
+		t.Errorf("Expected the route table on the handler node, got %+v", user.routes)
+	}
+}
+
+func TestRouteFromContext(t *testing.T) {
+	var inner *RouteInfo
+	rb := NewRouterBuilder()
+	rb.AddRouteFunc("GET", "/users/:id", func(w http.ResponseWriter, r *http.Request, params PathParams) {
+		inner = RouteFromContext(r.Context())
+	}, WithName("user"))
+	router, _ := rb.Build()
+
+	// Without a recorder the context is left alone
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+	if inner != nil {
+		t.Errorf("Expected no route without RecordRoute, got %+v", *inner)
+	}
+
+	// A middleware installs the recorder and reads the route after the handler
+	req := RecordRoute(httptest.NewRequest("GET", "/users/42", nil))
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	outer := RouteFromContext(req.Context())
+	if outer == nil || outer.Pattern != "/users/:id" || outer.Name != "user" {
+		t.Fatalf("Expected the middleware to see /users/:id, got %+v", outer)
+	}
+	if inner != outer {
+		t.Errorf("Expected the handler to see the same route as the middleware")
+	}
+	if params := RouteParamsFromContext(req.Context()); params["id"] != "42" {
+		t.Errorf("Expected the middleware to see id=42 after release, got %v", params)
+	}
+	if RecordRoute(req) != req {
+		t.Error("Expected RecordRoute to reuse an existing recorder")
+	}
+
+	// Recording into an installed slot keeps ServeHTTP allocation-free
+	allocRouter := NewRouterBuilder()
+	allocRouter.AddRoute("GET", "/direct/:id", paramsSink{})
+	router, _ = allocRouter.Build()
+	w := &discardWriter{header: make(http.Header)}
+	req = RecordRoute(httptest.NewRequest("GET", "/direct/42", nil))
+	if allocs := testing.AllocsPerRun(100, func() { router.ServeHTTP(w, req) }); allocs != 0 {
+		t.Errorf("ServeHTTP with RecordRoute: expected 0 allocs/op, got %.1f", allocs)
+	}
+}
+
+func TestWithRouteContext(t *testing.T) {
+	var got *RouteInfo
+	sub := NewRouterBuilder()
+	sub.AddRoute("GET", "/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = RouteFromContext(r.Context())
+	}))
+	subRouter, _ := sub.Build()
+
+	rb := NewRouterBuilder(WithRouteContext())
+	rb.Mount("/api/{version}", subRouter)
+	router, _ := rb.Build()
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/users/42", nil))
+	if got == nil || got.Pattern != "/api/{version}/users/:id" {
+		t.Errorf("Expected the grafted pattern /api/{version}/users/:id, got %+v", got)
+	}
+}
+
+func TestRecordRouteNested(t *testing.T) {
+	var inner *RouteInfo
+	sub := NewRouterBuilder()
+	sub.AddRoute("GET", "/", textHandler("index"), WithName("index"))
+	sub.AddRoute("GET", "/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inner = RouteFromContext(r.Context())
+	}), WithName("user"))
+	subRouter, _ := sub.Build()
+	wrapped := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { subRouter.ServeHTTP(w, r) })
+
+	rb := NewRouterBuilder()
+	rb.Mount("/api/{version}", wrapped)
+	rb.AddRoute("GET", "/legacy/*", http.StripPrefix("/legacy", subRouter))
+	router, _ := rb.Build()
+
+	testCases := []struct {
+		path    string
+		pattern string
+		name    string
+		params  PathParams
+	}{
+		// A router below Mount records its route under the mount's pattern
+		{"/api/v1/users/42", "/api/{version}/users/:id", "user", PathParams{"version": "v1", "id": "42"}},
+		{"/api/v1", "/api/{version}", "index", PathParams{"version": "v1"}},
+		// Any other nesting leaves the innermost router's route as registered
+		{"/legacy/users/7", "/users/:id", "user", PathParams{"id": "7"}},
+	}
+
+	for _, tc := range testCases {
+		req := RecordRoute(httptest.NewRequest("GET", tc.path, nil))
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		route := RouteFromContext(req.Context())
+		if route == nil || route.Pattern != tc.pattern || route.Name != tc.name {
+			t.Errorf("%s: expected route %s named %q, got %+v", tc.path, tc.pattern, tc.name, route)
+			continue
+		}
+		if params := RouteParamsFromContext(req.Context()); !equalParams(params, tc.params) {
+			t.Errorf("%s: expected params %v, got %v", tc.path, tc.params, params)
+		}
+		if tc.name == "user" && inner != route {
+			t.Errorf("%s: expected the handler to see the recorded route, got %+v", tc.path, inner)
+		}
+	}
+}
+
+func TestWithMiddleware(t *testing.T) {
+	tag := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("X-Order", name+":"+GetPathParams(r)["id"])
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	rb := NewRouterBuilder()
+	users := rb.Group("/users", WithMiddleware(tag("group")))
+	users.AddRouteFunc("GET", "/:id", func(w http.ResponseWriter, r *http.Request, params PathParams) {
+		w.Header().Add("X-Order", "handler:"+params["id"])
+	}, WithMiddleware(tag("route1"), tag("route2")))
+	router, _ := rb.Build()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/users/42", nil))
+	got := strings.Join(w.Header().Values("X-Order"), ",")
+	if want := "group:42,route1:42,route2:42,handler:42"; got != want {
+		t.Errorf("Expected middleware order %q, got %q", want, got)
+	}
+}
+
+func TestWithRouteValue(t *testing.T) {
+	type key struct{}
+	rb := NewRouterBuilder()
+	rb.Group("/admin", WithRouteValue(key{}, "admin")).AddRoute("GET", "/users", textHandler("users"))
+	rb.AddRoute("GET", "/users", textHandler("users"))
+	router, _ := rb.Build()
+
+	if _, _, route := router.MatchRoute("GET", "/admin/users"); route.Value(key{}) != "admin" {
+		t.Errorf("Expected the group's value, got %v", route.Value(key{}))
+	}
+	if _, _, route := router.MatchRoute("GET", "/users"); route.Value(key{}) != nil {
+		t.Errorf("Expected no value, got %v", route.Value(key{}))
+	}
+}
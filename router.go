@@ -1,57 +1,129 @@
 package fastrouter
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"sort"
 	"sync"
 	"strings"
+	"time"
 )
 
 // Route represents a single route with method, path, and handler
 type Route struct {
 	Method  string
-	Path    string
+	Path    string // normalized to :name and * segments
 	Handler http.Handler
+	Pattern string        // path as registered, reported by RouteInfo
+	Name    string        // optional, set with WithName
+	Timeout time.Duration // optional, set with Timeout
+
+	MaxBodyBytes int64    // optional, set with MaxBodyBytes
+	Consumes     []string // accepted request media types, set with Consumes
+
+	// Middleware wraps the handler, first outermost, set with WithMiddleware
+	Middleware []func(http.Handler) http.Handler
+
+	// Values are reported by RouteInfo.Value, set with WithRouteValue
+	Values map[any]any
+
+	rank routeRank // how the route was registered, see resolveDuplicates
 }
 
+// routeRank orders the routes registered for the same method and path, lowest
+// first. Routes Handle and Mount add on behalf of a pattern give way to routes
+// registered for the method itself.
+type routeRank uint8
+
+const (
+	explicitRoute routeRank = iota // registered for its own method
+	impliedHead                    // HEAD served for a GET pattern
+	impliedMethod                  // method of a method-less pattern or mounted handler
+)
+
 // RouterBuilder is used to collect routes before building the final router
 type RouterBuilder struct {
-	routes []Route
-	built  bool
+	routes         []Route
+	mounts         []mount
+	built          bool
+	perMethodTrees bool
+	panicHandler   func(http.ResponseWriter, *http.Request, any)
+	notFound       http.Handler
+	routeContext   bool
+	tracer         Tracer
 }
 
+// Option configures a RouterBuilder
+type Option func(*RouterBuilder)
+
 // Router represents the built, immutable router with fast lookup
 type Router struct {
-	root *node
+	root       *node              // shared trie, nil when built WithPerMethodTrees
+	static     bool               // true when no route has a parameter or wildcard
+	statics    *staticTable       // perfect hash of the fully static routes, nil if none
+	trees      [methodCount]*node // per-method tries for the standard methods
+	extraTrees map[string]*node   // per-method tries for extension methods
+	perMethod  bool               // true when built WithPerMethodTrees
+	methods    []string           // every registered method, probed for 405 responses
+	anyMethod  bool               // true when a route answers every extension method
+	routes     []Route            // the routes it was built from, grafted when mounted
+
+	panicHandler func(http.ResponseWriter, *http.Request, any) // nil for the default report
+	notFound     http.Handler                                  // nil for http.NotFound
+	routeContext bool                                          // install a route slot on every request
+	tracer       Tracer                                        // nil unless built WithTracer
 }
 
 // node represents a node in our FST-like trie structure
 type node struct {
-	segment   string                   // path segment for this node
-	methods   map[string]http.Handler  // handlers for different HTTP methods at this exact path
-	children  map[string]*node         // child nodes
-	paramName string                   // if this is a parameter segment, the parameter name
-	isParam   bool                     // true if this node represents a path parameter
-	isWild    bool                     // true if this is a wildcard node
-	wildChild *node                    // wildcard child node
+	segment      string                    // path segment for this node
+	handlers     [methodCount]http.Handler // handlers for the standard HTTP methods, indexed by methodIndex
+	extraMethods map[string]http.Handler   // handlers for extension methods (PROPFIND, REPORT, ...), nil until used
+	children     map[string]*node          // static child nodes, keyed by segment
+	paramChild   []*node                   // parameter child nodes, tried in registration order
+	paramName    string                    // if this is a parameter or wildcard segment, the parameter name
+	isParam      bool                      // true if this node represents a path parameter
+	isWild       bool                      // true if this is a wildcard node
+	wildChild    *node                     // wildcard child node
+	pattern      string                    // path of the routes registered on this node
+	routes       *nodeRoutes               // routes of the handlers, nil on nodes without any
+}
+
+// nodeRoutes holds the RouteInfo of each handler of a node. It sits behind a
+// pointer so that intermediate nodes, which have no handlers, stay small.
+type nodeRoutes struct {
+	standard [methodCount]*RouteInfo // indexed by methodIndex
+	extra    map[string]*RouteInfo   // extension methods, nil until used
 }
 
 // NewRouterBuilder creates a new router builder
-func NewRouterBuilder() *RouterBuilder {
-	return &RouterBuilder{
+func NewRouterBuilder(opts ...Option) *RouterBuilder {
+	rb := &RouterBuilder{
 		routes: make([]Route, 0),
 		built:  false,
 	}
+	for _, opt := range opts {
+		opt(rb)
+	}
+	return rb
 }
 
 // AddRoute adds a route to the builder. Routes must be added in lexicographic order
-// of their paths for optimal performance.
-func (rb *RouterBuilder) AddRoute(method, path string, handler http.Handler) error {
+// of their paths for optimal performance. Paths use :name parameters and a trailing
+// * (or *name) wildcard, or the equivalent Go 1.22 ServeMux {name} and {name...}.
+func (rb *RouterBuilder) AddRoute(method, path string, handler http.Handler, opts ...RouteOption) error {
 	if rb.built {
 		return fmt.Errorf("cannot add routes to a built router")
 	}
 
+	// Accept Go 1.22 ServeMux wildcards ({id}, {path...}, {$}) as well
+	pattern := path
+	path, err := ConvertPattern(path)
+	if err != nil {
+		return err
+	}
+
 	// Validate that routes are being added in lexicographic order
 	if len(rb.routes) > 0 {
 		lastPath := rb.routes[len(rb.routes)-1].Path
@@ -64,6 +136,10 @@ func (rb *RouterBuilder) AddRoute(method, path string, handler http.Handler) err
 		Method:  strings.ToUpper(method),
 		Path:    path,
 		Handler: handler,
+		Pattern: pattern,
+	}
+	for _, opt := range opts {
+		opt(&route)
 	}
 
 	rb.routes = append(rb.routes, route)
@@ -78,29 +154,106 @@ func (rb *RouterBuilder) Build() (*Router, error) {
 
 	rb.built = true
 
-	// Sort routes by path to ensure lexicographic order
-	sort.Slice(rb.routes, func(i, j int) bool {
+	// Mounted routers and handlers join the routes in any order
+	for _, m := range rb.mounts {
+		rb.routes = append(rb.routes, m.routes()...)
+	}
+
+	routes, err := resolveDuplicates(rb.routes)
+	if err != nil {
+		return nil, err
+	}
+	rb.routes = routes
+
+	// Sort routes by path to ensure lexicographic order, keeping the
+	// registration order of the methods of one path
+	sort.SliceStable(rb.routes, func(i, j int) bool {
 		return rb.routes[i].Path < rb.routes[j].Path
 	})
 
 	router := &Router{
-		root: &node{
-			segment:  "",
-			methods:  make(map[string]http.Handler),
-			children: make(map[string]*node),
-		},
+		perMethod:    rb.perMethodTrees,
+		routes:       rb.routes,
+		panicHandler: rb.panicHandler,
+		notFound:     rb.notFound,
+		routeContext: rb.routeContext,
+		tracer:       rb.tracer,
+	}
+	if !router.perMethod {
+		router.root = newRootNode()
 	}
 
 	// Build the trie structure
+	seen := make(map[string]bool)
 	for _, route := range rb.routes {
 		router.addRoute(route)
+		if route.Method == anyMethod {
+			router.anyMethod = true
+		} else if !seen[route.Method] {
+			seen[route.Method] = true
+			router.methods = append(router.methods, route.Method)
+		}
+	}
+	sort.Strings(router.methods)
+
+	// Routers without params or wildcards never need to look past exact children
+	router.static = true
+	for _, root := range router.roots() {
+		if checkForParams(root) {
+			router.static = false
+		}
 	}
+	router.statics = buildStaticTable(router.roots())
 
 	return router, nil
 }
 
+// resolveDuplicates keeps one route per method and path: the one of the lowest
+// rank, so an explicit route wins over a method-less pattern or a mount that
+// also covers it. Two routes of the same rank are a conflict, as in ServeMux.
+func resolveDuplicates(routes []Route) ([]Route, error) {
+	type key struct{ method, path string }
+	winner := make(map[key]int, len(routes))
+	dropped := false
+	for i, route := range routes {
+		k := key{route.Method, route.Path}
+		w, exists := winner[k]
+		switch {
+		case !exists:
+			winner[k] = i
+		case route.rank == routes[w].rank:
+			return nil, fmt.Errorf("route %s %s registered twice", route.Method, route.Path)
+		case route.rank < routes[w].rank:
+			winner[k] = i
+			dropped = true
+		default:
+			dropped = true
+		}
+	}
+	if !dropped {
+		return routes, nil
+	}
+
+	kept := make([]Route, 0, len(winner))
+	for i, route := range routes {
+		if winner[key{route.Method, route.Path}] == i {
+			kept = append(kept, route)
+		}
+	}
+	return kept, nil
+}
+
+// newRootNode creates the empty root of a trie
+func newRootNode() *node {
+	return &node{
+		segment:  "",
+		children: make(map[string]*node),
+	}
+}
+
 // addRoute adds a single route to the router's trie structure
 func (r *Router) addRoute(route Route) {
+	route.Handler = route.compile()
 	path := route.Path
 	if path == "" || path[0] != '/' {
 		path = "/" + path
@@ -111,7 +264,8 @@ func (r *Router) addRoute(route Route) {
 		segments = []string{} // Handle root path "/"
 	}
 
-	current := r.root
+	root := r.treeForRoute(route.Method)
+	current := root
 	for i, segment := range segments {
 		isLast := i == len(segments)-1
 
@@ -120,8 +274,8 @@ func (r *Router) addRoute(route Route) {
 			paramName := segment[1:]
 			// Look for existing parameter child
 			found := false
-			for _, child := range current.children {
-				if child.isParam && child.paramName == paramName {
+			for _, child := range current.paramChild {
+				if child.paramName == paramName {
 					current = child
 					found = true
 					break
@@ -130,22 +284,25 @@ func (r *Router) addRoute(route Route) {
 			if !found {
 				newNode := &node{
 					segment:   segment,
-					methods:   make(map[string]http.Handler),
 					children:  make(map[string]*node),
 					paramName: paramName,
 					isParam:   true,
 				}
-				current.children[segment] = newNode
+				current.paramChild = append(current.paramChild, newNode)
 				current = newNode
 			}
-		} else if segment == "*" {
-			// Handle wildcard
+		} else if strings.HasPrefix(segment, "*") {
+			// Handle wildcard, captured as "*" unless named like *filepath
 			if current.wildChild == nil {
+				wildName := segment[1:]
+				if wildName == "" {
+					wildName = "*"
+				}
 				current.wildChild = &node{
-					segment:  "*",
-					methods:  make(map[string]http.Handler),
-					children: make(map[string]*node),
-					isWild:   true,
+					segment:   segment,
+					children:  make(map[string]*node),
+					paramName: wildName,
+					isWild:    true,
 				}
 			}
 			current = current.wildChild
@@ -156,7 +313,6 @@ func (r *Router) addRoute(route Route) {
 			} else {
 				newNode := &node{
 					segment:  segment,
-					methods:  make(map[string]http.Handler),
 					children: make(map[string]*node),
 				}
 				current.children[segment] = newNode
@@ -166,91 +322,239 @@ func (r *Router) addRoute(route Route) {
 
 		// If this is the last segment, add the handler
 		if isLast {
-			current.methods[route.Method] = route.Handler
+			current.setRoute(route, path)
 		}
 	}
 
 	// Handle root path
 	if len(segments) == 0 {
-		r.root.methods[route.Method] = route.Handler
+		root.setRoute(route, path)
 	}
 }
 
-// ServeHTTP implements http.Handler interface
+// ServeHTTP implements http.Handler interface. Path parameters are passed to
+// ParamsHandlers directly and are otherwise available through GetPathParams.
+// Panics in handlers are recovered, see WithPanicHandler.
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	handler, params := r.Match(req.Method, req.URL.Path)
+	if r.tracer != nil {
+		r.serveTraced(w, req)
+		return
+	}
+	handler, route, params := r.lookup(req.Method, req.URL.Path)
+	r.serve(w, req, handler, route, params)
+}
+
+// serve dispatches a looked up request, answering 405 or 404 on a miss
+func (r *Router) serve(w http.ResponseWriter, req *http.Request, handler http.Handler, route *RouteInfo, params PathParams) {
 	if handler != nil {
-		// Store parameters in request context if needed
-		if len(params) > 0 {
-			// For now, we'll just call the handler directly
-			// In a full implementation, you'd want to store params in context
+		if slot := routeSlotFrom(req.Context()); slot != nil {
+			slot.record(route, params)
+		} else if r.routeContext {
+			req = req.WithContext(context.WithValue(req.Context(), routeKey, &routeSlot{route: route}))
 		}
-		handler.ServeHTTP(w, req)
+
+		rw := acquireResponseWriter(w)
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				r.recoverPanic(rw, req, route, recovered)
+			}
+			ReleaseParams(params)
+			releaseResponseWriter(rw)
+		}()
+		serveMatched(rw, req, handler, params)
+	} else if allowed := r.AllowedMethods(req.URL.Path); len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
 	} else {
-		http.NotFound(w, req)
+		r.serveNotFound(w, req)
 	}
 }
 
 // PathParams represents extracted path parameters
 type PathParams map[string]string
 
-// Match finds a handler for the given method and path
+// ParamsHandler is implemented by handlers that take path parameters as an
+// argument. Router.ServeHTTP passes them directly instead of storing them in the
+// request context, which keeps dispatch allocation-free. For the same reason the
+// params are not set with r.SetPathValue, so r.PathValue and GetPathParams are
+// empty in a ParamsHandler. The params map is pooled and only valid until
+// ServeHTTPParams returns.
+type ParamsHandler interface {
+	ServeHTTPParams(w http.ResponseWriter, r *http.Request, params PathParams)
+}
+
+// serveMatched dispatches a matched request, handing params to ParamsHandlers
+// directly and to other handlers through the request context
+func serveMatched(w http.ResponseWriter, req *http.Request, handler http.Handler, params PathParams) {
+	if ph, ok := handler.(ParamsHandler); ok {
+		ph.ServeHTTPParams(w, req, params)
+		return
+	}
+
+	// The context may outlive the handler, so it gets a copy of the pooled map
+	handler.ServeHTTP(w, WithPathParams(req, copyParams(params)))
+}
+
+// copyParams returns a copy of params owned by the caller, or nil if empty
+func copyParams(params PathParams) PathParams {
+	if len(params) == 0 {
+		return nil
+	}
+	owned := make(PathParams, len(params))
+	for k, v := range params {
+		owned[k] = v
+	}
+	return owned
+}
+
+// Match finds a handler for the given method and path. Static routes return nil
+// params; param and wildcard routes return a pooled map which the caller may hand
+// back with ReleaseParams once it is no longer used, making Match allocation-free.
 func (r *Router) Match(method, path string) (http.Handler, PathParams) {
+	handler, _, params := r.lookup(method, path)
+	return handler, params
+}
+
+// MatchRoute is Match that also returns the matched route, whose Pattern is
+// the path template such as "/api/users/:id". It is nil when no route matches.
+func (r *Router) MatchRoute(method, path string) (http.Handler, PathParams, *RouteInfo) {
+	handler, route, params := r.lookup(method, path)
+	return handler, params, route
+}
+
+// lookup is Match that also returns the matched route. An extension method
+// without a route of its own falls back to the routes of every method.
+func (r *Router) lookup(method, path string) (http.Handler, *RouteInfo, PathParams) {
+	handler, route, params := r.lookupMethod(method, path)
+	if handler == nil && r.anyMethod && method != anyMethod && methodIndex(method) == noMethodIndex {
+		return r.lookupMethod(anyMethod, path)
+	}
+	return handler, route, params
+}
+
+// lookupMethod matches path against the routes registered for method
+func (r *Router) lookupMethod(method, path string) (http.Handler, *RouteInfo, PathParams) {
 	if path == "" || path[0] != '/' {
 		path = "/" + path
 	}
 
-	segments := strings.Split(path, "/")[1:] // Skip empty first element
-	if len(segments) == 1 && segments[0] == "" {
-		segments = []string{} // Handle root path "/"
+	mi := methodIndex(method)
+	root := r.tree(mi, method)
+	if root == nil {
+		return nil, nil, nil
 	}
 
-	params := make(PathParams)
-	handler := r.matchNode(r.root, segments, method, params)
-	return handler, params
+	// The root path "/" has no segments, start past the end of the path
+	start := 1
+	if len(path) == 1 {
+		start = 2
+	}
+
+	// Fully static routes are answered from the perfect hash. A static path
+	// always wins over params in the trie, so a hit needs no walk.
+	if r.statics != nil {
+		if n := r.statics.lookup(path); n != nil {
+			if handler := n.handler(mi, method); handler != nil {
+				return handler, n.route(mi, method), nil
+			}
+		}
+		if r.static {
+			return nil, nil, nil // the table holds every route
+		}
+	} else if r.static {
+		if n := r.matchStatic(root, path, start, mi, method); n != nil {
+			return n.handler(mi, method), n.route(mi, method), nil
+		}
+		return nil, nil, nil
+	}
+
+	n, params := r.match(root, path, start, mi, method, nil)
+	if n == nil {
+		if params != nil {
+			ReleaseParams(params)
+		}
+		return nil, nil, nil
+	}
+	return n.handler(mi, method), n.route(mi, method), params
 }
 
-// matchNode recursively matches path segments against the trie
-func (r *Router) matchNode(n *node, segments []string, method string, params PathParams) http.Handler {
+// match recursively matches the segment of path beginning at start against the
+// children of n and returns the leaf with a handler for the method. A start past
+// the end of the path means every segment has been consumed. The params map is
+// only taken from the pool once a value is captured.
+func (r *Router) match(n *node, path string, start int, mi int, method string, params PathParams) (*node, PathParams) {
 	// If we've consumed all segments, check if this node has a handler for the method
-	if len(segments) == 0 {
-		if handler, exists := n.methods[method]; exists {
-			return handler
+	if start > len(path) {
+		if n.handler(mi, method) != nil {
+			return n, params
 		}
-		return nil
+		return nil, params
 	}
 
-	segment := segments[0]
-	remaining := segments[1:]
+	// Find the end of current segment
+	end := start
+	for end < len(path) && path[end] != '/' {
+		end++
+	}
+	segment := path[start:end]
 
 	// Try exact match first
 	if child, exists := n.children[segment]; exists {
-		if handler := r.matchNode(child, remaining, method, params); handler != nil {
-			return handler
+		var leaf *node
+		if leaf, params = r.match(child, path, end+1, mi, method, params); leaf != nil {
+			return leaf, params
 		}
 	}
 
 	// Try parameter match
-	for _, child := range n.children {
-		if child.isParam {
-			params[child.paramName] = segment
-			if handler := r.matchNode(child, remaining, method, params); handler != nil {
-				return handler
-			}
-			delete(params, child.paramName) // backtrack
+	for _, child := range n.paramChild {
+		if params == nil {
+			params = AcquireParams()
 		}
+		params[child.paramName] = segment
+
+		var leaf *node
+		if leaf, params = r.match(child, path, end+1, mi, method, params); leaf != nil {
+			return leaf, params
+		}
+		delete(params, child.paramName) // backtrack
 	}
 
-	// Try wildcard match
+	// Try wildcard match, which captures everything remaining
 	if n.wildChild != nil {
-		// Wildcard matches everything remaining
-		params["*"] = strings.Join(segments, "/")
-		if handler, exists := n.wildChild.methods[method]; exists {
-			return handler
+		if n.wildChild.handler(mi, method) != nil {
+			if params == nil {
+				params = AcquireParams()
+			}
+			params[n.wildChild.paramName] = path[start:]
+			return n.wildChild, params
 		}
 	}
 
-	return nil
+	return nil, params
+}
+
+// matchStatic walks a trie without parameter or wildcard nodes, where every
+// segment has at most one candidate child, and returns the leaf with a handler
+// for the method
+func (r *Router) matchStatic(n *node, path string, start int, mi int, method string) *node {
+	for start <= len(path) {
+		end := start
+		for end < len(path) && path[end] != '/' {
+			end++
+		}
+
+		child, exists := n.children[path[start:end]]
+		if !exists {
+			return nil
+		}
+		n = child
+		start = end + 1
+	}
+	if n.handler(mi, method) == nil {
+		return nil
+	}
+	return n
 }
 
 // Stats returns statistics about the router structure
@@ -265,17 +569,22 @@ func (r *Router) Stats() map[string]interface{} {
 		if depth > maxDepth {
 			maxDepth = depth
 		}
-		routeCount += len(n.methods)
+		routeCount += n.routeCount()
 		
 		for _, child := range n.children {
 			countNodes(child, depth+1)
 		}
+		for _, child := range n.paramChild {
+			countNodes(child, depth+1)
+		}
 		if n.wildChild != nil {
 			countNodes(n.wildChild, depth+1)
 		}
 	}
 
-	countNodes(r.root, 0)
+	for _, root := range r.roots() {
+		countNodes(root, 0)
+	}
 
 	return map[string]interface{}{
 		"nodes":     nodeCount,
@@ -285,19 +594,28 @@ func (r *Router) Stats() map[string]interface{} {
 }
 
 // PathParamsKey is the context key for path parameters
-type contextKey struct{}
-
-var pathParamsKey = &contextKey{}
+type contextKey struct {
+	name string
+}
 
+var pathParamsKey = &contextKey{"path-params"}
 
-// GetPathParams extracts path parameters from the request context
+// GetPathParams extracts path parameters from the request context. The map
+// Router.ServeHTTP stores there belongs to the request and stays valid after
+// the handler returns, unlike the pooled map a ParamsHandler receives.
 func GetPathParams(r *http.Request) PathParams {
-	if params, ok := r.Context().Value(pathParamsKey).(PathParams); ok {
+	if params := PathParamsFromContext(r.Context()); params != nil {
 		return params
 	}
 	return make(PathParams)
 }
 
+// PathParamsFromContext returns the path parameters stored in ctx, or nil
+func PathParamsFromContext(ctx context.Context) PathParams {
+	params, _ := ctx.Value(pathParamsKey).(PathParams)
+	return params
+}
+
 // RouteCount returns the total number of routes
 func (r *Router) RouteCount() int {
 	stats := r.Stats()
@@ -310,72 +628,20 @@ func (r *Router) NodeCount() int {
 	return stats["nodes"].(int)
 }
 
-// MatchOptimized - optimized version that avoids string allocations
-func (r *Router) MatchOptimized(method, path string) (http.Handler, PathParams) {
-	if path == "" || path[0] != '/' {
-		path = "/" + path
+// checkForParams recursively checks if the trie contains any parameter or wildcard nodes
+func checkForParams(n *node) bool {
+	if n.isParam || n.isWild || len(n.paramChild) > 0 || n.wildChild != nil {
+		return true
 	}
 
-	params := make(PathParams)
-	handler := r.matchPathOptimized(r.root, path, 1, method, params)
-	return handler, params
-}
-
-// matchPathOptimized matches a path without creating string slices
-func (r *Router) matchPathOptimized(n *node, path string, start int, method string, params PathParams) http.Handler {
-	// If we've consumed the entire path, check for handler
-	if start >= len(path) {
-		if handler, exists := n.methods[method]; exists {
-			return handler
-		}
-		return nil
-	}
-	
-	// Find the end of current segment
-	end := start
-	for end < len(path) && path[end] != '/' {
-		end++
-	}
-	
-	segment := path[start:end]
-	
-	// Calculate next segment start position
-	nextStart := end + 1
-	if nextStart > len(path) {
-		nextStart = len(path)
-	}
-	
-	// Try exact match first (most common case)
-	if child, exists := n.children[segment]; exists {
-		if handler := r.matchPathOptimized(child, path, nextStart, method, params); handler != nil {
-			return handler
-		}
-	}
-	
-	// Try parameter matches
 	for _, child := range n.children {
-		if child.isParam {
-			params[child.paramName] = segment
-			if handler := r.matchPathOptimized(child, path, nextStart, method, params); handler != nil {
-				return handler
-			}
-			delete(params, child.paramName) // backtrack
+		if checkForParams(child) {
+			return true
 		}
 	}
-	
-	// Try wildcard match (matches rest of path)
-	if n.wildChild != nil {
-		if start < len(path) {
-			params["*"] = path[start:]
-		}
-		if handler, exists := n.wildChild.methods[method]; exists {
-			return handler
-		}
-	}
-	
-	return nil
-}
 
+	return false
+}
 
 // Pool for PathParams to avoid allocations
 var paramsPool = sync.Pool{
@@ -384,79 +650,17 @@ var paramsPool = sync.Pool{
 	},
 }
 
-// MatchOptimized2 - optimized version with parameter pooling
-func (r *Router) MatchOptimized2(method, path string) (http.Handler, PathParams) {
-	if path == "" || path[0] != '/' {
-		path = "/" + path
-	}
-
-	// Check if this route could have parameters
-	hasParams := false
-	for i := 1; i < len(path); i++ {
-		if path[i] == ':' || path[i] == '*' {
-			hasParams = true
-			break
-		}
-	}
-	
-	var params PathParams
-	var handler http.Handler
-	
-	if hasParams {
-		params = paramsPool.Get().(PathParams)
-		// Clear any existing params
-		for k := range params {
-			delete(params, k)
-		}
-		handler = r.matchPathOptimized(r.root, path, 1, method, params)
-		
-		// Return empty params to pool if no matches found
-		if handler == nil {
-			paramsPool.Put(params)
-			params = nil
-		}
-	} else {
-		// For static routes, don't allocate params at all
-		handler = r.matchPathOptimizedStatic(r.root, path, 1, method)
-	}
-	
-	return handler, params
-}
-
-// matchPathOptimizedStatic - for static routes without parameters
-func (r *Router) matchPathOptimizedStatic(n *node, path string, start int, method string) http.Handler {
-	// If we've consumed the entire path, check for handler
-	if start >= len(path) {
-		if handler, exists := n.methods[method]; exists {
-			return handler
-		}
-		return nil
-	}
-	
-	// Find the end of current segment
-	end := start
-	for end < len(path) && path[end] != '/' {
-		end++
-	}
-	
-	segment := path[start:end]
-	nextStart := end + 1
-	if nextStart > len(path) {
-		nextStart = len(path)
-	}
-	
-	// Only try exact matches for static routes
-	if child, exists := n.children[segment]; exists && !child.isParam {
-		return r.matchPathOptimizedStatic(child, path, nextStart, method)
-	}
-	
-	return nil
+// AcquireParams takes an empty parameter map from the pool
+// Matchers outside this package (such as code generated by fastrouter-gen) use it
+// so their params can be handed back with ReleaseParams
+func AcquireParams() PathParams {
+	return paramsPool.Get().(PathParams)
 }
 
 // ReleaseParams returns parameter map to the pool for reuse
 // Call this after processing a request with parameters to optimize memory usage
 func ReleaseParams(params PathParams) {
-	if params != nil && len(params) > 0 {
+	if params != nil {
 		// Clear the map before returning to pool
 		for k := range params {
 			delete(params, k)
@@ -465,8 +669,26 @@ func ReleaseParams(params PathParams) {
 	}
 }
 
-// FastMatch is an alias for the optimized matching function
-// This is the recommended method for high-performance applications
+// MatchOptimized is the former slice-free variant of Match.
+//
+// Deprecated: Match no longer allocates; use it instead.
+func (r *Router) MatchOptimized(method, path string) (http.Handler, PathParams) {
+	return r.Match(method, path)
+}
+
+// MatchOptimized2 is the former pooled variant of Match. It guessed whether a
+// route had params by scanning the request path for ':' or '*', which sent
+// requests like /users/123 down the static path.
+//
+// Deprecated: Match pools params and takes its static shortcut from the route
+// table; use it instead.
+func (r *Router) MatchOptimized2(method, path string) (http.Handler, PathParams) {
+	return r.Match(method, path)
+}
+
+// FastMatch is an alias for Match, kept for existing callers.
+//
+// Deprecated: use Match.
 func (r *Router) FastMatch(method, path string) (http.Handler, PathParams) {
-	return r.MatchOptimized2(method, path)
+	return r.Match(method, path)
 }
@@ -5,90 +5,51 @@ import (
 )
 
 // FixedRouter wraps the original Router with a corrected FastMatch implementation
+//
+// Deprecated: Router.Match takes its static-only shortcut from the route table
+// at Build time, which is what FixedRouter was added for. Use *Router directly.
 type FixedRouter struct {
 	*Router
-	hasParams bool // Pre-computed flag indicating if router has any parameterized routes
 }
 
 // NewFixedRouter creates a router with the corrected FastMatch behavior
+//
+// Deprecated: use the *Router returned by RouterBuilder.Build.
 func NewFixedRouter(router *Router) *FixedRouter {
-	// Check if router has any parameterized routes by examining the structure
-	hasParams := checkForParams(router.root)
-	
-	return &FixedRouter{
-		Router:    router,
-		hasParams: hasParams,
-	}
-}
-
-// checkForParams recursively checks if the trie contains any parameter or wildcard nodes
-func checkForParams(n *node) bool {
-	if n.isParam || n.isWild {
-		return true
-	}
-	
-	for _, child := range n.children {
-		if checkForParams(child) {
-			return true
-		}
-	}
-	
-	if n.wildChild != nil && checkForParams(n.wildChild) {
-		return true
-	}
-	
-	return false
+	return &FixedRouter{Router: router}
 }
 
 // FastMatch provides the corrected fast matching implementation
+//
+// Deprecated: use Router.Match.
 func (fr *FixedRouter) FastMatch(method, path string) (http.Handler, PathParams) {
-	if path == "" || path[0] != '/' {
-		path = "/" + path
-	}
-
-	var params PathParams
-	var handler http.Handler
-	
-	if fr.hasParams {
-		// Router has parameterized routes, use full matching with parameter pooling
-		params = paramsPool.Get().(PathParams)
-		// Clear any existing params
-		for k := range params {
-			delete(params, k)
-		}
-		handler = fr.matchPathOptimized(fr.root, path, 1, method, params)
-		
-		// Return empty params to pool if no matches found
-		if handler == nil {
-			paramsPool.Put(params)
-			params = nil
-		}
-	} else {
-		// Router has only static routes, use static optimization
-		handler = fr.matchPathOptimizedStatic(fr.root, path, 1, method)
-	}
-	
-	return handler, params
+	return fr.Match(method, path)
 }
 
 // Enhanced RouterBuilder that builds FixedRouters
+//
+// Deprecated: use RouterBuilder.
 type EnhancedRouterBuilder struct {
 	*RouterBuilder
 }
 
 // NewEnhancedRouterBuilder creates a new enhanced router builder
+//
+// Deprecated: use NewRouterBuilder.
 func NewEnhancedRouterBuilder() *EnhancedRouterBuilder {
 	return &EnhancedRouterBuilder{
 		RouterBuilder: NewRouterBuilder(),
 	}
 }
 
-// Build constructs a FixedRouter with corrected FastMatch behavior  
+// Build constructs a FixedRouter with corrected FastMatch behavior
+//
+// Deprecated: use RouterBuilder.Build.
 func (erb *EnhancedRouterBuilder) Build() (*FixedRouter, error) {
 	router, err := erb.RouterBuilder.Build()
 	if err != nil {
 		return nil, err
 	}
-	
+
 	return NewFixedRouter(router), nil
-}
\ No newline at end of file
+}
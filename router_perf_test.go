@@ -1,5 +1,7 @@
 package fastrouter
 
+//go:generate go run ./cmd/fastrouter-gen -spec testdata/perf_routes.txt -o perf_routes_gen_test.go -pkg fastrouter -type perfRoutes
+
 import (
 	"fmt"
 	"net/http"
@@ -16,6 +18,109 @@ func (h *testHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(h.name))
 }
 
+// Handlers referenced by the generated perfRoutes matcher (testdata/perf_routes.txt)
+var (
+	perfHome          = &testHandler{name: "home"}
+	perfAdminHome     = &testHandler{name: "admin_home"}
+	perfAdminUsers    = &testHandler{name: "admin_users"}
+	perfAPIHome       = &testHandler{name: "api_home"}
+	perfAPIUsers      = &testHandler{name: "api_users"}
+	perfAPICreateUser = &testHandler{name: "api_create_user"}
+	perfAPIUser       = &testHandler{name: "api_user"}
+	perfAPIPosts      = &testHandler{name: "api_posts"}
+	perfStatic        = &testHandler{name: "static"}
+	perfUsers         = &testHandler{name: "users"}
+	perfUser          = &testHandler{name: "user"}
+	perfUserSettings  = &testHandler{name: "user_settings"}
+)
+
+// buildPerfRouter builds a Router from the same route table as perfRoutes
+func buildPerfRouter(tb testing.TB) *Router {
+	rb := NewRouterBuilder()
+	routes := []struct {
+		method  string
+		path    string
+		handler http.Handler
+	}{
+		{"GET", "/", perfHome},
+		{"GET", "/admin", perfAdminHome},
+		{"GET", "/admin/users", perfAdminUsers},
+		{"GET", "/api", perfAPIHome},
+		{"GET", "/api/users", perfAPIUsers},
+		{"POST", "/api/users", perfAPICreateUser},
+		{"GET", "/api/users/:id", perfAPIUser},
+		{"GET", "/api/users/:id/posts", perfAPIPosts},
+		{"GET", "/static/*", perfStatic},
+		{"GET", "/users", perfUsers},
+		{"GET", "/users/:id", perfUser},
+		{"GET", "/users/:id/settings", perfUserSettings},
+	}
+
+	for _, route := range routes {
+		if err := rb.AddRoute(route.method, route.path, route.handler); err != nil {
+			tb.Fatalf("Failed to add route %s %s: %v", route.method, route.path, err)
+		}
+	}
+
+	router, err := rb.Build()
+	if err != nil {
+		tb.Fatalf("Failed to build router: %v", err)
+	}
+	return router
+}
+
+func TestGeneratedMatcher(t *testing.T) {
+	router := buildPerfRouter(t)
+	var generated perfRoutes
+
+	testCases := []struct{ method, path string }{
+		{"GET", "/"},
+		{"GET", ""},
+		{"GET", "/admin"},
+		{"GET", "/admin/users"},
+		{"GET", "/admin/other"},
+		{"GET", "/api"},
+		{"GET", "/api/users"},
+		{"POST", "/api/users"},
+		{"DELETE", "/api/users"},
+		{"GET", "/api/users/42"},
+		{"GET", "/api/users/42/posts"},
+		{"GET", "/api/users/42/comments"},
+		{"GET", "/static/css/site.css"},
+		{"GET", "/static/"},
+		{"GET", "/static"},
+		{"GET", "/users"},
+		{"GET", "/users/"},
+		{"GET", "/users/7"},
+		{"GET", "/users/7/settings"},
+		{"GET", "/nonexistent"},
+		{"GET", "//"},
+	}
+
+	for _, tc := range testCases {
+		wantHandler, wantParams := router.Match(tc.method, tc.path)
+		gotHandler, gotParams := generated.Match(tc.method, tc.path)
+
+		if gotHandler != wantHandler {
+			t.Errorf("%s %s: generated matcher returned %v, router returned %v", tc.method, tc.path, gotHandler, wantHandler)
+			continue
+		}
+		if wantHandler == nil {
+			continue
+		}
+		if len(gotParams) != len(wantParams) {
+			t.Errorf("%s %s: generated params %v, router params %v", tc.method, tc.path, gotParams, wantParams)
+			continue
+		}
+		for key, want := range wantParams {
+			if got := gotParams[key]; got != want {
+				t.Errorf("%s %s: generated param %s=%q, router has %q", tc.method, tc.path, key, got, want)
+			}
+		}
+		ReleaseParams(gotParams)
+	}
+}
+
 func TestCurrentRouterPerformance(t *testing.T) {
 	fmt.Println("=== Current Router Performance Analysis ===")
 	
@@ -135,3 +240,39 @@ func BenchmarkCurrentRouter(b *testing.B) {
 		router.Match("GET", path)
 	}
 }
+
+func BenchmarkGeneratedMatcher(b *testing.B) {
+	router := buildPerfRouter(b)
+	var generated perfRoutes
+
+	testPaths := []string{
+		"/",
+		"/users",
+		"/users/123",
+		"/users/123/settings",
+		"/api/users/456",
+		"/api/users/456/posts",
+	}
+
+	b.Run("Router", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			router.Match("GET", testPaths[i%len(testPaths)])
+		}
+	})
+
+	b.Run("Generated", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, params := generated.Match("GET", testPaths[i%len(testPaths)])
+			ReleaseParams(params)
+		}
+	})
+
+	b.Run("Generated-Static", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			generated.Match("GET", "/admin/users")
+		}
+	})
+}
@@ -322,6 +322,79 @@ func TestRouterStats(t *testing.T) {
 	t.Logf("Router stats: %+v", stats)
 }
 
+func TestExtensionMethods(t *testing.T) {
+	rb := NewRouterBuilder()
+
+	methodHandler := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(name))
+		})
+	}
+
+	// WebDAV/CalDAV methods are stored outside the fixed per-node method array
+	routes := []struct {
+		method string
+		path   string
+	}{
+		{"GET", "/calendars/:user"},
+		{"PROPFIND", "/calendars/:user"},
+		{"report", "/calendars/:user"},
+		{"PROPFIND", "/files/*"},
+	}
+
+	for _, route := range routes {
+		if err := rb.AddRoute(route.method, route.path, methodHandler(route.method)); err != nil {
+			t.Fatalf("Error adding route %s %s: %v", route.method, route.path, err)
+		}
+	}
+
+	router, err := rb.Build()
+	if err != nil {
+		t.Fatalf("Error building router: %v", err)
+	}
+
+	testCases := []struct {
+		method   string
+		path     string
+		expected string
+	}{
+		{"GET", "/calendars/alice", "GET"},
+		{"PROPFIND", "/calendars/alice", "PROPFIND"},
+		{"REPORT", "/calendars/alice", "report"},
+		{"PROPFIND", "/files/docs/a.txt", "PROPFIND"},
+		{"MKCOL", "/calendars/alice", ""},
+		{"POST", "/calendars/alice", ""},
+		{"GET", "/files/docs/a.txt", ""},
+	}
+
+	for _, tc := range testCases {
+		handler, params := router.Match(tc.method, tc.path)
+		if tc.expected == "" {
+			if handler != nil {
+				t.Errorf("Expected no handler for %s %s, got one", tc.method, tc.path)
+			}
+			continue
+		}
+		if handler == nil {
+			t.Errorf("Expected handler for %s %s, got nil", tc.method, tc.path)
+			continue
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		if w.Body.String() != tc.expected {
+			t.Errorf("Expected response '%s' for %s %s, got '%s'", tc.expected, tc.method, tc.path, w.Body.String())
+		}
+		if tc.path == "/calendars/alice" && params["user"] != "alice" {
+			t.Errorf("Expected param user=alice for %s %s, got %q", tc.method, tc.path, params["user"])
+		}
+	}
+
+	if router.RouteCount() != len(routes) {
+		t.Errorf("Expected route count of %d, got %d", len(routes), router.RouteCount())
+	}
+}
+
 func TestRouterServeHTTP(t *testing.T) {
 	rb := NewRouterBuilder()
 
@@ -362,6 +435,203 @@ func TestRouterServeHTTP(t *testing.T) {
 	}
 }
 
+func TestMethodNotAllowed(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"SharedTree", nil},
+		{"PerMethodTrees", []Option{WithPerMethodTrees()}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rb := NewRouterBuilder(tc.opts...)
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(r.Method))
+			})
+
+			rb.AddRoute("GET", "/users", handler)
+			rb.AddRoute("POST", "/users", handler)
+			rb.AddRoute("DELETE", "/users/:id", handler)
+			rb.AddRoute("GET", "/users/:id", handler)
+
+			router, err := rb.Build()
+			if err != nil {
+				t.Fatalf("Error building router: %v", err)
+			}
+
+			testCases := []struct {
+				method         string
+				path           string
+				expectedStatus int
+				expectedAllow  string
+			}{
+				{"GET", "/users", http.StatusOK, ""},
+				{"POST", "/users", http.StatusOK, ""},
+				{"DELETE", "/users/1", http.StatusOK, ""},
+				{"PUT", "/users", http.StatusMethodNotAllowed, "GET, POST"},
+				{"POST", "/users/1", http.StatusMethodNotAllowed, "DELETE, GET"},
+				{"PROPFIND", "/users/1", http.StatusMethodNotAllowed, "DELETE, GET"},
+				{"GET", "/posts", http.StatusNotFound, ""},
+			}
+
+			for _, tc := range testCases {
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+
+				if w.Code != tc.expectedStatus {
+					t.Errorf("Expected status %d for %s %s, got %d", tc.expectedStatus, tc.method, tc.path, w.Code)
+				}
+				if allow := w.Header().Get("Allow"); allow != tc.expectedAllow {
+					t.Errorf("Expected Allow '%s' for %s %s, got '%s'", tc.expectedAllow, tc.method, tc.path, allow)
+				}
+			}
+
+			if allocs := testing.AllocsPerRun(100, func() { router.AllowedMethods("/posts/1") }); allocs != 0 {
+				t.Errorf("Expected no allocations for a path without routes, got %v", allocs)
+			}
+		})
+	}
+}
+
+func TestPerMethodTreesMatchSharedTree(t *testing.T) {
+	routes := []struct {
+		method string
+		path   string
+	}{
+		{"GET", "/"},
+		{"GET", "/api/users"},
+		{"POST", "/api/users"},
+		{"GET", "/api/users/:id"},
+		{"PUT", "/api/users/:id"},
+		{"POST", "/api/users/:id/avatar"},
+		{"DELETE", "/api/users/new"},
+		{"GET", "/files/*"},
+		{"PROPFIND", "/files/*"},
+	}
+
+	handlers := make(map[string]http.Handler)
+	for _, route := range routes {
+		name := route.method + " " + route.path
+		handlers[name] = &testHandler{name: name}
+	}
+
+	build := func(opts ...Option) *Router {
+		rb := NewRouterBuilder(opts...)
+		for _, route := range routes {
+			rb.AddRoute(route.method, route.path, handlers[route.method+" "+route.path])
+		}
+		router, err := rb.Build()
+		if err != nil {
+			t.Fatalf("Error building router: %v", err)
+		}
+		return router
+	}
+
+	shared := build()
+	perMethod := build(WithPerMethodTrees())
+
+	if shared.RouteCount() != perMethod.RouteCount() {
+		t.Errorf("Expected equal route counts, got %d shared and %d per-method", shared.RouteCount(), perMethod.RouteCount())
+	}
+
+	paths := []string{"/", "/api/users", "/api/users/1", "/api/users/new", "/api/users/1/avatar", "/files/a/b", "/files/", "/missing"}
+	methods := []string{"GET", "POST", "PUT", "DELETE", "PROPFIND"}
+
+	for _, path := range paths {
+		// One walk of the shared trie agrees with probing each method's trie
+		if got, want := shared.AllowedMethods(path), perMethod.AllowedMethods(path); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%s: shared tree allows %v, per-method trees allow %v", path, got, want)
+		}
+		for _, method := range methods {
+			sharedHandler, sharedParams := shared.Match(method, path)
+			perMethodHandler, perMethodParams := perMethod.Match(method, path)
+
+			if sharedHandler != perMethodHandler {
+				t.Errorf("%s %s: shared tree returned %v, per-method trees returned %v", method, path, sharedHandler, perMethodHandler)
+				continue
+			}
+			if sharedHandler != nil && fmt.Sprint(sharedParams) != fmt.Sprint(perMethodParams) {
+				t.Errorf("%s %s: shared params %v, per-method params %v", method, path, sharedParams, perMethodParams)
+			}
+		}
+	}
+}
+
+// paramsEcho is a ParamsHandler that writes the id param
+type paramsEcho struct{}
+
+func (paramsEcho) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("context:" + GetPathParams(r)["id"]))
+}
+
+func (paramsEcho) ServeHTTPParams(w http.ResponseWriter, r *http.Request, params PathParams) {
+	w.Write([]byte("direct:" + params["id"]))
+}
+
+// paramsSink is a ParamsHandler that reads the id param without allocating
+type paramsSink struct{}
+
+var paramsSinkID string
+
+func (paramsSink) ServeHTTP(w http.ResponseWriter, r *http.Request) {}
+
+func (paramsSink) ServeHTTPParams(w http.ResponseWriter, r *http.Request, params PathParams) {
+	paramsSinkID = params["id"]
+}
+
+// discardWriter is a ResponseWriter that does not allocate
+type discardWriter struct {
+	header http.Header
+}
+
+func (d *discardWriter) Header() http.Header         { return d.header }
+func (d *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (d *discardWriter) WriteHeader(int)             {}
+
+func TestServeHTTPParams(t *testing.T) {
+	rb := NewRouterBuilder()
+	rb.AddRoute("GET", "/direct/:id", paramsEcho{})
+	rb.AddRoute("GET", "/plain/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("context:" + GetPathParams(r)["id"]))
+	}))
+	rb.AddRoute("GET", "/static", paramsEcho{})
+	router, err := rb.Build()
+	if err != nil {
+		t.Fatalf("Error building router: %v", err)
+	}
+
+	for _, tc := range []struct{ path, expected string }{
+		{"/direct/42", "direct:42"},
+		{"/plain/42", "context:42"},
+		{"/static", "direct:"},
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", tc.path, nil))
+		if w.Body.String() != tc.expected {
+			t.Errorf("Expected response '%s' for %s, got '%s'", tc.expected, tc.path, w.Body.String())
+		}
+	}
+
+	allocRouter := NewRouterBuilder()
+	allocRouter.AddRoute("GET", "/direct/:id", paramsSink{})
+	allocRouter.AddRoute("GET", "/static", paramsSink{})
+	router, err = allocRouter.Build()
+	if err != nil {
+		t.Fatalf("Error building router: %v", err)
+	}
+
+	w := &discardWriter{header: make(http.Header)}
+	for _, path := range []string{"/direct/42", "/static"} {
+		req := httptest.NewRequest("GET", path, nil)
+		allocs := testing.AllocsPerRun(100, func() {
+			router.ServeHTTP(w, req)
+		})
+		if allocs != 0 {
+			t.Errorf("ServeHTTP(GET %s): expected 0 allocs/op, got %.1f", path, allocs)
+		}
+	}
+}
+
 func TestCannotModifyBuiltRouter(t *testing.T) {
 	rb := NewRouterBuilder()
 
@@ -421,6 +691,21 @@ func BenchmarkRouter_ParamRoute(b *testing.B) {
 	}
 }
 
+func BenchmarkRouter_ParamsHandler(b *testing.B) {
+	rb := NewRouterBuilder()
+	rb.AddRoute("GET", "/api/users/:id", paramsSink{})
+	router, _ := rb.Build()
+
+	req := httptest.NewRequest("GET", "/api/users/123", nil)
+	w := &discardWriter{header: make(http.Header)}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		router.ServeHTTP(w, req)
+	}
+}
+
 func BenchmarkRouter_MatchOnly(b *testing.B) {
 	rb := NewRouterBuilder()
 	rb.AddRoute("GET", "/api/users", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
@@ -495,9 +780,9 @@ func BenchmarkComparison_Static(b *testing.B) {
 	
 	b.Run("FastRouter-Optimized", func(b *testing.B) {
 		for i := 0; i < b.N; i++ {
-			handler, params := router.MatchOptimized2("GET", "/api/users")
+			handler, params := router.Match("GET", "/api/users")
 			_ = handler
-			_ = params
+			ReleaseParams(params)
 		}
 	})
 }
@@ -518,11 +803,55 @@ func BenchmarkComparison_Params(b *testing.B) {
 	
 	b.Run("FastRouter-Optimized", func(b *testing.B) {
 		for i := 0; i < b.N; i++ {
-			handler, params := router.MatchOptimized2("GET", "/api/users/123")
+			handler, params := router.Match("GET", "/api/users/123")
 			_ = handler
-			if params != nil {
-				paramsPool.Put(params)
-			}
+			ReleaseParams(params)
 		}
 	})
 }
+
+func BenchmarkComparison_MethodTrees(b *testing.B) {
+	// GET and POST routes with very different shapes
+	routes := []struct{ method, path string }{
+		{"GET", "/api/reports/:year/:month"},
+		{"GET", "/api/users"},
+		{"GET", "/api/users/:id"},
+		{"GET", "/api/users/:id/posts"},
+		{"GET", "/api/users/:id/posts/:post"},
+		{"POST", "/api/webhooks/github"},
+		{"GET", "/static/*"},
+	}
+
+	build := func(opts ...Option) *Router {
+		rb := NewRouterBuilder(opts...)
+		for _, route := range routes {
+			rb.AddRoute(route.method, route.path, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		}
+		router, _ := rb.Build()
+		return router
+	}
+
+	requests := []struct{ method, path string }{
+		{"GET", "/api/users/123/posts/456"},
+		{"POST", "/api/webhooks/github"},
+		{"POST", "/api/users/123/posts/456"}, // miss
+		{"DELETE", "/api/users/123"},         // miss
+	}
+
+	for _, layout := range []struct {
+		name   string
+		router *Router
+	}{
+		{"SharedTree", build()},
+		{"PerMethodTrees", build(WithPerMethodTrees())},
+	} {
+		b.Run(layout.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				req := requests[i%len(requests)]
+				_, params := layout.router.Match(req.method, req.path)
+				ReleaseParams(params)
+			}
+		})
+	}
+}
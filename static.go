@@ -0,0 +1,215 @@
+package fastrouter
+
+import (
+	"sort"
+)
+
+// staticTable is a perfect hash of every fully static route path, consulted by
+// Match before the trie walk. It uses hash-and-displace: the path hash picks a
+// bucket, and the bucket's seed picks a collision-free slot.
+type staticTable struct {
+	seeds      []uint32      // per-bucket displacement seed
+	entries    []staticEntry // slots, at most one path each
+	bucketMask uint64
+	slotMask   uint64
+}
+
+// staticEntry holds the handlers registered for one static path
+type staticEntry struct {
+	path string
+	node *node // copy of the handlers, merged across per-method tries
+}
+
+const (
+	maxSeedTries   = 1 << 14 // seeds tried per bucket before growing the table
+	maxTableGrowth = 4       // doublings before giving up on a perfect hash
+)
+
+// lookup returns the node registered for path, or nil
+func (t *staticTable) lookup(path string) *node {
+	h := hashPath(path)
+	e := &t.entries[mixSeed(h, t.seeds[h&t.bucketMask])&t.slotMask]
+	if e.node != nil && e.path == path {
+		return e.node
+	}
+	return nil
+}
+
+// hashPath is 64-bit FNV-1a
+func hashPath(s string) uint64 {
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= 1099511628211
+	}
+	return h
+}
+
+// mixSeed derives an independent slot hash from a path hash and a bucket seed
+func mixSeed(h uint64, seed uint32) uint64 {
+	h ^= uint64(seed) * 0x9e3779b97f4a7c15
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	return h
+}
+
+// buildStaticTable collects the static routes of every trie and hashes them.
+// It returns nil when there are no static routes or no perfect hash was found,
+// in which case Match relies on the trie alone.
+func buildStaticTable(roots []*node) *staticTable {
+	byPath := make(map[string]*node)
+	for _, root := range roots {
+		collectStatic(root, "", true, byPath)
+	}
+	if len(byPath) == 0 {
+		return nil
+	}
+
+	entries := make([]staticEntry, 0, len(byPath))
+	for path, n := range byPath {
+		entries = append(entries, staticEntry{path: path, node: n})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].path < entries[j].path
+	})
+
+	slots := nextPowerOfTwo(len(entries) + len(entries)/4)
+	buckets := nextPowerOfTwo((len(entries) + 1) / 2)
+	for growth := 0; growth < maxTableGrowth; growth++ {
+		if t := tryStaticTable(entries, buckets, slots); t != nil {
+			return t
+		}
+		slots *= 2
+	}
+	return nil
+}
+
+// collectStatic records every node reachable through static children that has
+// a handler, keyed by the request path Match normalizes to. Handlers of the
+// same path from different per-method tries are merged into one node.
+func collectStatic(n *node, prefix string, isRoot bool, byPath map[string]*node) {
+	if n.routeCount() > 0 {
+		path := prefix
+		if isRoot {
+			path = "/"
+		}
+
+		// A root child with an empty segment is unreachable: Match treats "/"
+		// as the root itself
+		if isRoot || prefix != "/" {
+			merged, exists := byPath[path]
+			if !exists {
+				merged = &node{segment: n.segment, pattern: n.pattern}
+				byPath[path] = merged
+			}
+			mergeHandlers(merged, n)
+		}
+	}
+
+	for segment, child := range n.children {
+		collectStatic(child, prefix+"/"+segment, false, byPath)
+	}
+}
+
+// mergeHandlers copies every handler of src onto dst
+func mergeHandlers(dst, src *node) {
+	for i, h := range src.handlers {
+		if h != nil {
+			dst.handlers[i] = h
+		}
+	}
+	for method, h := range src.extraMethods {
+		dst.setHandler(method, h)
+	}
+	if src.routes == nil {
+		return
+	}
+	for i, route := range src.routes.standard {
+		if route != nil {
+			dst.setRouteInfo(standardMethods[i], route)
+		}
+	}
+	for method, route := range src.routes.extra {
+		dst.setRouteInfo(method, route)
+	}
+}
+
+// tryStaticTable searches a displacement seed for every bucket, placing the
+// largest buckets first. It returns nil if some bucket has no valid seed.
+func tryStaticTable(entries []staticEntry, buckets, slots int) *staticTable {
+	t := &staticTable{
+		seeds:      make([]uint32, buckets),
+		entries:    make([]staticEntry, slots),
+		bucketMask: uint64(buckets - 1),
+		slotMask:   uint64(slots - 1),
+	}
+
+	hashes := make([]uint64, len(entries))
+	bucketEntries := make([][]int, buckets)
+	for i, e := range entries {
+		hashes[i] = hashPath(e.path)
+		b := hashes[i] & t.bucketMask
+		bucketEntries[b] = append(bucketEntries[b], i)
+	}
+
+	order := make([]int, buckets)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return len(bucketEntries[order[i]]) > len(bucketEntries[order[j]])
+	})
+
+	taken := make([]bool, slots)
+	placed := make([]uint64, 0, 8)
+	for _, b := range order {
+		members := bucketEntries[b]
+		if len(members) == 0 {
+			break
+		}
+
+		found := false
+		for seed := uint32(0); seed < maxSeedTries && !found; seed++ {
+			placed = placed[:0]
+			found = true
+			for _, i := range members {
+				slot := mixSeed(hashes[i], seed) & t.slotMask
+				if taken[slot] || containsSlot(placed, slot) {
+					found = false
+					break
+				}
+				placed = append(placed, slot)
+			}
+			if found {
+				t.seeds[b] = seed
+				for k, i := range members {
+					taken[placed[k]] = true
+					t.entries[placed[k]] = entries[i]
+				}
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+
+	return t
+}
+
+func containsSlot(slots []uint64, slot uint64) bool {
+	for _, s := range slots {
+		if s == slot {
+			return true
+		}
+	}
+	return false
+}
+
+func nextPowerOfTwo(n int) int {
+	p := 1
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
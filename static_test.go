@@ -0,0 +1,117 @@
+package fastrouter
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestStaticTable(t *testing.T) {
+	for _, layout := range []struct {
+		name string
+		opts []Option
+	}{
+		{"SharedTree", nil},
+		{"PerMethodTrees", []Option{WithPerMethodTrees()}},
+	} {
+		t.Run(layout.name, func(t *testing.T) {
+			rb := NewRouterBuilder(layout.opts...)
+			handlers := make(map[string]http.Handler)
+
+			// Enough routes to need several buckets and displacement seeds
+			var routes []struct{ method, path string }
+			for i := 0; i < 2000; i++ {
+				routes = append(routes, struct{ method, path string }{"GET", fmt.Sprintf("/api/v1/resource%04d", i)})
+			}
+			routes = append(routes, []struct{ method, path string }{
+				{"GET", "/api/v1/users/:id"},
+				{"GET", "/api/v1/users/me"},
+				{"PUT", "/api/v1/users/me"},
+				{"GET", "/healthz"},
+				{"POST", "/healthz"},
+			}...)
+
+			for _, route := range routes {
+				name := route.method + " " + route.path
+				handlers[name] = &testHandler{name: name}
+				if err := rb.AddRoute(route.method, route.path, handlers[name]); err != nil {
+					t.Fatalf("Error adding route %s: %v", name, err)
+				}
+			}
+
+			router, err := rb.Build()
+			if err != nil {
+				t.Fatalf("Error building router: %v", err)
+			}
+			if router.statics == nil {
+				t.Fatal("Expected a static route table")
+			}
+
+			for _, route := range routes[:2000] {
+				path := route.path
+				handler, params := router.Match("GET", path)
+				if handler != handlers["GET "+path] {
+					t.Fatalf("Expected static handler for GET %s, got %v", path, handler)
+				}
+				if params != nil {
+					t.Errorf("Expected nil params for static route %s, got %v", path, params)
+				}
+			}
+
+			testCases := []struct {
+				method   string
+				path     string
+				expected string
+				params   PathParams
+			}{
+				{"GET", "/healthz", "GET /healthz", nil},
+				{"POST", "/healthz", "POST /healthz", nil},
+				{"GET", "/api/v1/users/me", "GET /api/v1/users/me", nil},
+				{"PUT", "/api/v1/users/me", "PUT /api/v1/users/me", nil},
+				// Static path without a handler for the method falls back to the trie
+				{"GET", "/api/v1/users/42", "GET /api/v1/users/:id", PathParams{"id": "42"}},
+				{"DELETE", "/healthz", "", nil},
+				{"GET", "/api/v1/resource9999", "", nil},
+			}
+
+			for _, tc := range testCases {
+				handler, params := router.Match(tc.method, tc.path)
+				if handler != handlers[tc.expected] {
+					t.Errorf("%s %s: expected %q, got %v", tc.method, tc.path, tc.expected, handler)
+				}
+				if !equalParams(params, tc.params) {
+					t.Errorf("%s %s: expected params %v, got %v", tc.method, tc.path, tc.params, params)
+				}
+				ReleaseParams(params)
+			}
+		})
+	}
+}
+
+func TestStaticTableRoot(t *testing.T) {
+	rb := NewRouterBuilder()
+	rb.AddRoute("GET", "/", &testHandler{name: "root"})
+	rb.AddRoute("GET", "//double", &testHandler{name: "double"})
+	router, err := rb.Build()
+	if err != nil {
+		t.Fatalf("Error building router: %v", err)
+	}
+
+	for _, tc := range []struct{ path, expected string }{
+		{"/", "root"},
+		{"", "root"},
+		{"//double", "double"},
+		{"/double", ""},
+	} {
+		handler, _ := router.Match("GET", tc.path)
+		if tc.expected == "" {
+			if handler != nil {
+				t.Errorf("Expected no handler for %q, got %v", tc.path, handler)
+			}
+			continue
+		}
+		if th, ok := handler.(*testHandler); !ok || th.name != tc.expected {
+			t.Errorf("Expected handler %q for %q, got %v", tc.expected, tc.path, handler)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package fastrouter
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Timeout bounds how long a route's handler may run. The handler's request
+// context gets a deadline of d; when it passes before the handler wrote a
+// header, the client gets a 503 Service Unavailable and later writes fail with
+// http.ErrHandlerTimeout. A handler that already started its response is left
+// to finish it, and should stop once its context is done. A panic in a handler
+// that outlived its 503 is logged with slog, as the router has already
+// returned and cannot recover it.
+func Timeout(d time.Duration) RouteOption {
+	return func(route *Route) {
+		route.Timeout = d
+	}
+}
+
+// timeoutHandler runs handler in its own goroutine under a deadline, so the
+// router can answer when the deadline passes
+type timeoutHandler struct {
+	handler http.Handler
+	timeout time.Duration
+}
+
+func (h *timeoutHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.ServeHTTPParams(w, r, GetPathParams(r))
+}
+
+// ServeHTTPParams runs the handler with a copy of params, since the router
+// releases its pooled map when the deadline passes and ServeHTTP returns
+func (h *timeoutHandler) ServeHTTPParams(w http.ResponseWriter, r *http.Request, params PathParams) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+	r = r.WithContext(ctx)
+
+	owned := copyParams(params)
+
+	tw := &timeoutWriter{w: w, header: make(http.Header)}
+	done := make(chan struct{})
+	panicked := make(chan any, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				// Handed over under the lock, so that either the router
+				// still waits and re-raises it, or the 503 went out and
+				// nobody will, and it is logged here
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				if !tw.timedOut {
+					panicked <- recovered
+				} else if recovered != http.ErrAbortHandler {
+					slog.ErrorContext(r.Context(), "fastrouter: panic serving request after timeout",
+						"method", r.Method,
+						"path", r.URL.Path,
+						"request_id", GetRequestID(r),
+						"panic", recovered,
+						"stack", string(debug.Stack()),
+					)
+				}
+			}
+		}()
+		serveMatched(tw, r, h.handler, owned)
+		close(done)
+	}()
+
+	select {
+	case recovered := <-panicked:
+		// Re-raised here so the router's panic handling sees it
+		panic(recovered)
+	case <-done:
+	case <-ctx.Done():
+		tw.mu.Lock()
+		select {
+		case recovered := <-panicked:
+			tw.mu.Unlock()
+			panic(recovered)
+		default:
+		}
+		if !tw.wroteHeader {
+			tw.timedOut = true
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(http.StatusText(http.StatusServiceUnavailable)))
+			tw.mu.Unlock()
+			return
+		}
+		tw.mu.Unlock()
+
+		// The response has started, so it is the handler's to finish
+		select {
+		case recovered := <-panicked:
+			panic(recovered)
+		case <-done:
+		}
+	}
+}
+
+// timeoutWriter guards the response against the router writing the timeout
+// response concurrently. The handler gets its own header map, copied to the
+// response when the header is written.
+type timeoutWriter struct {
+	w      http.ResponseWriter
+	header http.Header
+
+	mu          sync.Mutex
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.writeHeaderLocked(http.StatusOK)
+	}
+	return tw.w.Write(b)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.writeHeaderLocked(code)
+}
+
+func (tw *timeoutWriter) writeHeaderLocked(code int) {
+	dst := tw.w.Header()
+	for k, v := range tw.header {
+		dst[k] = v
+	}
+	tw.wroteHeader = true
+	tw.w.WriteHeader(code)
+}
+
+// Flush sends buffered data, so streaming handlers keep working under a timeout
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return
+	}
+	if !tw.wroteHeader {
+		tw.writeHeaderLocked(http.StatusOK)
+	}
+	if f, ok := tw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
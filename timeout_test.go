@@ -0,0 +1,166 @@
+package fastrouter
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	writeErr := make(chan error, 1)
+	rb := NewRouterBuilder()
+	api := rb.Group("/api", Timeout(20*time.Millisecond))
+	api.AddRouteFunc("GET", "/fast/:id", func(w http.ResponseWriter, r *http.Request, params PathParams) {
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Error("Expected a deadline on the request context")
+		}
+		w.Header().Set("X-Id", params["id"])
+		io.WriteString(w, "fast")
+	})
+	api.AddRoute("GET", "/plain/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(40 * time.Millisecond) // the router has released its params by now
+		io.WriteString(w, GetPathParams(r)["id"])
+	}), Timeout(time.Second))
+	api.AddRoute("GET", "/slow", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		time.Sleep(5 * time.Millisecond)
+		_, err := io.WriteString(w, "late")
+		writeErr <- err
+	}))
+	api.AddRoute("GET", "/streaming", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "started ")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+		io.WriteString(w, "done")
+	}))
+	router, err := rb.Build()
+	if err != nil {
+		t.Fatalf("Error building router: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/fast/7", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "fast" || w.Header().Get("X-Id") != "7" {
+		t.Errorf("Expected the fast handler's response, got %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+
+	// A route option overrides the group's timeout, and params outlive the router's pool
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/plain/9", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "9" {
+		t.Errorf("Expected the route timeout to apply, got %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/slow", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 on timeout, got %d", w.Code)
+	}
+	if err := <-writeErr; !errors.Is(err, http.ErrHandlerTimeout) {
+		t.Errorf("Expected late writes to fail with http.ErrHandlerTimeout, got %v", err)
+	}
+
+	// A started response is finished by the handler
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/streaming", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "started done" || !w.Flushed {
+		t.Errorf("Expected the streamed response to complete, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestTimeoutPanic(t *testing.T) {
+	var got any
+	rb := NewRouterBuilder(WithPanicHandler(func(w http.ResponseWriter, r *http.Request, recovered any) {
+		got = recovered
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	rb.AddRoute("GET", "/boom", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}), Timeout(time.Second))
+	router, _ := rb.Build()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/boom", nil))
+	if got != "boom" || w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected the panic to reach the panic handler, got %v and %d", got, w.Code)
+	}
+}
+
+// chanWriter sends each write to the channel, for logs written by another goroutine
+type chanWriter chan string
+
+func (c chanWriter) Write(p []byte) (int, error) {
+	c <- string(p)
+	return len(p), nil
+}
+
+func TestTimeoutPanicAfterResponse(t *testing.T) {
+	logs := make(chanWriter, 1)
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(logs, nil)))
+
+	rb := NewRouterBuilder()
+	rb.AddRoute("GET", "/late", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		time.Sleep(5 * time.Millisecond)
+		panic("late boom")
+	}), Timeout(10*time.Millisecond))
+	router, _ := rb.Build()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/late", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected 503 on timeout, got %d", w.Code)
+	}
+
+	// The router has returned, so the panic can only be logged
+	select {
+	case record := <-logs:
+		for _, want := range []string{"after timeout", "path=/late", "panic=\"late boom\"", "stack="} {
+			if !strings.Contains(record, want) {
+				t.Errorf("Expected %q in the log record, got %s", want, record)
+			}
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected the late panic to be logged")
+	}
+}
+
+func TestGroup(t *testing.T) {
+	rb := NewRouterBuilder()
+	api := rb.Group("/api/")
+	api.AddRoute("GET", "/", textHandler("api"))
+	v1 := api.Group("/v1", WithName("v1"))
+	v1.AddRoute("GET", "/users/:id", textHandler("user"))
+	v1.AddRoute("GET", "users/:id/posts", textHandler("posts"), WithName("posts"))
+	router, err := rb.Build()
+	if err != nil {
+		t.Fatalf("Error building router: %v", err)
+	}
+
+	for _, tc := range []struct{ path, body, name string }{
+		{"/api", "api", ""},
+		{"/api/v1/users/1", "user", "v1"},
+		{"/api/v1/users/1/posts", "posts", "posts"},
+	} {
+		handler, params, route := router.MatchRoute("GET", tc.path)
+		if handler == nil {
+			t.Errorf("%s: expected a match", tc.path)
+			continue
+		}
+		ReleaseParams(params)
+		if route.Name != tc.name {
+			t.Errorf("%s: expected name %q, got %q", tc.path, tc.name, route.Name)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", tc.path, nil))
+		if !strings.Contains(w.Body.String(), tc.body) {
+			t.Errorf("%s: expected %q, got %q", tc.path, tc.body, w.Body.String())
+		}
+	}
+}
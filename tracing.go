@@ -0,0 +1,84 @@
+package fastrouter
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/jamra/fastrouter/internal/respwriter"
+)
+
+// Tracer starts a span per request. It is small enough to back with the
+// OpenTelemetry SDK or a test recorder:
+//
+//	func (t otelTracer) Start(ctx context.Context, name string, info fastrouter.SpanInfo) (context.Context, fastrouter.Span) {
+//		ctx, span := t.tracer.Start(ctx, name, trace.WithTimestamp(info.Start))
+//		span.AddEvent("route lookup", trace.WithTimestamp(info.Start.Add(info.Lookup)))
+//		...
+//	}
+type Tracer interface {
+	// Start is called once the route is looked up, before the handler runs.
+	// The returned context is passed on to the handler.
+	Start(ctx context.Context, name string, info SpanInfo) (context.Context, Span)
+}
+
+// Span is a request span started by a Tracer
+type Span interface {
+	// End is called after the handler returns, or after a 405 or 404 was
+	// written, with the response status
+	End(status int)
+}
+
+// SpanInfo describes the request a span is started for
+type SpanInfo struct {
+	Method string
+	Path   string
+	Route  *RouteInfo // matched route, nil on a miss
+	Depth  int        // depth of the matched node in the trie, 0 on a miss
+	// Params are the path parameters of the match. The map is pooled and only
+	// valid until Start returns, so copy what the span needs.
+	Params PathParams
+	Start  time.Time     // when the router received the request
+	Lookup time.Duration // time spent matching the route, before the handler
+}
+
+// WithTracer makes Router.ServeHTTP start a span for every request through t.
+// Spans are named "METHOD /route/:pattern", or the method alone on a miss, and
+// end after the handler with the status it wrote. Tracing costs the
+// allocations of the Tracer and of the request carrying its context.
+func WithTracer(t Tracer) Option {
+	return func(rb *RouterBuilder) {
+		rb.tracer = t
+	}
+}
+
+// serveTraced serves req inside a span, timing the lookup separately from
+// the handler
+func (r *Router) serveTraced(w http.ResponseWriter, req *http.Request) {
+	start := time.Now()
+	handler, route, params := r.lookup(req.Method, req.URL.Path)
+	info := SpanInfo{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Route:  route,
+		Params: params,
+		Start:  start,
+		Lookup: time.Since(start),
+	}
+	name := req.Method
+	if route != nil {
+		name = req.Method + " " + route.Pattern
+		info.Depth = route.depth
+	}
+
+	ctx, span := r.tracer.Start(req.Context(), name, info)
+	rw := respwriter.Wrap(w)
+	defer func() {
+		status := rw.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.End(status)
+	}()
+	r.serve(rw, req.WithContext(ctx), handler, route, params)
+}
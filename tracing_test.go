@@ -0,0 +1,106 @@
+package fastrouter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type spanKey struct{}
+
+// recordedSpan is a span captured by testTracer
+type recordedSpan struct {
+	name   string
+	info   SpanInfo
+	params map[string]string
+	status int
+	ended  bool
+}
+
+func (s *recordedSpan) End(status int) {
+	s.status = status
+	s.ended = true
+}
+
+// testTracer records the spans it starts
+type testTracer struct {
+	spans []*recordedSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, name string, info SpanInfo) (context.Context, Span) {
+	span := &recordedSpan{name: name, info: info, params: make(map[string]string)}
+	for k, v := range info.Params {
+		span.params[k] = v
+	}
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func TestTracer(t *testing.T) {
+	tracer := &testTracer{}
+	var inHandler Span
+	rb := NewRouterBuilder(WithTracer(tracer), WithPanicHandler(func(w http.ResponseWriter, r *http.Request, recovered any) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	rb.AddRoute("GET", "/", textHandler("home"))
+	rb.AddRoute("GET", "/boom", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	rb.AddRouteFunc("POST", "/orgs/:org/users/:id", func(w http.ResponseWriter, r *http.Request, params PathParams) {
+		inHandler, _ = r.Context().Value(spanKey{}).(Span)
+		w.WriteHeader(http.StatusCreated)
+	})
+	router, err := rb.Build()
+	if err != nil {
+		t.Fatalf("Error building router: %v", err)
+	}
+
+	for _, tc := range []struct {
+		method, path string
+		name         string
+		depth        int
+		status       int
+	}{
+		{"POST", "/orgs/acme/users/42", "POST /orgs/:org/users/:id", 4, http.StatusCreated},
+		{"GET", "/", "GET /", 0, http.StatusOK},
+		{"GET", "/boom", "GET /boom", 1, http.StatusInternalServerError},
+		{"GET", "/orgs/acme/users/42", "GET", 0, http.StatusMethodNotAllowed},
+		{"GET", "/missing", "GET", 0, http.StatusNotFound},
+	} {
+		tracer.spans = nil
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, nil))
+		if len(tracer.spans) != 1 {
+			t.Fatalf("%s %s: expected one span, got %d", tc.method, tc.path, len(tracer.spans))
+		}
+		span := tracer.spans[0]
+		if span.name != tc.name {
+			t.Errorf("%s %s: expected span %q, got %q", tc.method, tc.path, tc.name, span.name)
+		}
+		if span.info.Depth != tc.depth {
+			t.Errorf("%s %s: expected depth %d, got %d", tc.method, tc.path, tc.depth, span.info.Depth)
+		}
+		if !span.ended || span.status != tc.status || rec.Code != tc.status {
+			t.Errorf("%s %s: expected span ended with %d, got ended=%v status=%d (response %d)",
+				tc.method, tc.path, tc.status, span.ended, span.status, rec.Code)
+		}
+		if span.info.Start.IsZero() || span.info.Lookup < 0 || span.info.Lookup > time.Since(span.info.Start) {
+			t.Errorf("%s %s: bad lookup timing %v from %v", tc.method, tc.path, span.info.Lookup, span.info.Start)
+		}
+	}
+
+	tracer.spans = nil
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/orgs/acme/users/42", nil))
+	span := tracer.spans[0]
+	if span.params["org"] != "acme" || span.params["id"] != "42" {
+		t.Errorf("Expected params org=acme id=42, got %v", span.params)
+	}
+	if span.info.Route == nil || span.info.Route.Pattern != "/orgs/:org/users/:id" {
+		t.Errorf("Expected the matched route on the span, got %+v", span.info.Route)
+	}
+	if inHandler != Span(span) {
+		t.Error("Expected the handler to see the span context")
+	}
+}